@@ -1,29 +1,95 @@
 package config
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"time"
+
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+const (
+	DefaultFlushInterval                = 30 * time.Second
+	DefaultTimeout                      = 10 * time.Second
+	DefaultExponentialHistogramMaxScale = 20
+	DefaultExponentialHistogramMaxSize  = 160
 )
 
 type Config struct {
-	Service       Service
-	Mode          Mode
-	SearchIndex   string
-	FlushInterval time.Duration
-	Timeout       time.Duration
+	Service       Service       `yaml:"service"`
+	Mode          Mode          `yaml:"mode"`
+	SearchIndex   string        `yaml:"search_index"`
+	FlushInterval time.Duration `yaml:"flush_interval"`
+	Timeout       time.Duration `yaml:"timeout"`
+
+	Port string `yaml:"port"`
+	// Endpoint replaces the mode's collector endpoint, as host:port, in Debug, Development and Production
+	Endpoint string `yaml:"endpoint"`
+	// OTLPHeaders are sent with every export to the collector, e.g. to authenticate
+	OTLPHeaders map[string]string `yaml:"otlp_headers"`
+
+	DefaultFields *map[string]string `yaml:"default_fields"`
+
+	// RecordErrorStacktrace attaches the stacktrace and its hash to errors recorded on spans
+	RecordErrorStacktrace bool `yaml:"record_error_stacktrace"`
+
+	// LogQueueSize and LogWorkers bound the asynchronous log pipeline; entries keep their order when LogWorkers is 1
+	LogQueueSize      int            `yaml:"log_queue_size"`
+	LogWorkers        int            `yaml:"log_workers"`
+	LogOverflowPolicy OverflowPolicy `yaml:"log_overflow_policy"`
+
+	// MetricExporter defaults to OTLPExporter
+	MetricExporter MetricExporter `yaml:"metric_exporter"`
+	// MetricTemporality defaults to Cumulative, the only one Prometheus supports
+	MetricTemporality Temporality `yaml:"metric_temporality"`
+	// MetricCardinalityLimit bounds the distinct attribute sets each metric records over the process lifetime. New sets
+	// beyond it are recorded into one series with otel.metric.overflow=true. Unlimited when 0
+	MetricCardinalityLimit int `yaml:"metric_cardinality_limit"`
+	// MetricAttributeAllowList keeps only the listed keys of the fields passed to metrics, when not empty
+	MetricAttributeAllowList []string `yaml:"metric_attribute_allow_list"`
+	// MetricAttributeDenyList drops the listed keys of the fields passed to metrics
+	MetricAttributeDenyList []string `yaml:"metric_attribute_deny_list"`
+	// HistogramBoundaries are the bucket boundaries of histograms without their own. The SDK defaults are used when empty
+	HistogramBoundaries []float64 `yaml:"histogram_boundaries"`
+	// Views rename metrics, drop attributes or change their aggregation, matched by instrument name
+	Views []View `yaml:"views"`
+	// ExponentialHistograms aggregates histograms without boundaries of their own over exponential buckets instead of
+	// HistogramBoundaries. Views can select the aggregation for single metrics with ExponentialHistogramAggregation
+	ExponentialHistograms bool `yaml:"exponential_histograms"`
+	// ExponentialHistogramMaxScale is the initial resolution of exponential histograms, between -10 and 20. Histograms
+	// lower it as needed to keep their buckets within ExponentialHistogramMaxSize. Defaults to 20 when nil
+	ExponentialHistogramMaxScale *int32 `yaml:"exponential_histogram_max_scale"`
+	// ExponentialHistogramMaxSize is the maximum number of buckets of each sign. Defaults to 160
+	ExponentialHistogramMaxSize int32 `yaml:"exponential_histogram_max_size"`
+	// RuntimeMetrics publishes the Go runtime metrics, e.g. go.goroutine.count and go.memory.used
+	RuntimeMetrics bool `yaml:"runtime_metrics"`
+	// ProcessMetrics publishes the process metrics read from /proc, e.g. process.cpu.time, and the limits of its cgroup
+	ProcessMetrics bool `yaml:"process_metrics"`
 
-	Port string
+	// TraceSampler defaults to ParentBasedAlwaysOnSampler
+	TraceSampler Sampler `yaml:"trace_sampler"`
+	// TraceSamplerRatio is the fraction of traces sampled by the ratio samplers, between 0 and 1. Defaults to 1 when
+	// nil, like the SDK
+	TraceSamplerRatio *float64 `yaml:"trace_sampler_ratio"`
 
-	DefaultFields *map[string]string
+	// ResourceDetectors add attributes to the resource shared by logs, metrics and traces, e.g. to describe where the
+	// instance runs. OTEL_RESOURCE_ATTRIBUTES overrides what they detect, and Service overrides both
+	ResourceDetectors []resource.Detector `yaml:"-"`
+	// DisableDefaultDetectors skips the built-in Kubernetes, container and cloud detectors, keeping ResourceDetectors
+	DisableDefaultDetectors bool `yaml:"disable_default_detectors"`
+
+	// ExitHook ends the process after a Fatal log once every signal is flushed. Defaults to os.Exit
+	ExitHook func(code int) `yaml:"-"`
 
 	hostname string
+	resource *resource.Resource
 }
 
 type Service struct {
-	Name    string
-	Version string
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
 }
 
 func (cfg *Config) Ensure() error {
@@ -40,11 +106,83 @@ func (cfg *Config) Ensure() error {
 	}
 
 	if cfg.FlushInterval <= 0 {
-		cfg.FlushInterval = 30
+		cfg.FlushInterval = DefaultFlushInterval
 	}
 
 	if cfg.Timeout <= 0 {
-		cfg.Timeout = 10
+		cfg.Timeout = DefaultTimeout
+	}
+
+	if cfg.LogOverflowPolicy != Block && cfg.LogOverflowPolicy != DropNewest && cfg.LogOverflowPolicy != DropOldest {
+		return errors.New("invalid log overflow policy")
+	}
+
+	if cfg.MetricTemporality != Cumulative && cfg.MetricTemporality != Delta && cfg.MetricTemporality != LowMemory {
+		return errors.New("invalid metric temporality")
+	}
+
+	if cfg.MetricExporter != OTLPExporter && cfg.MetricExporter != PrometheusExporter && cfg.MetricExporter != OTLPAndPrometheusExporter {
+		return errors.New("invalid metric exporter")
+	}
+
+	if cfg.MetricExporter.ServesPrometheus() && cfg.MetricTemporality != Cumulative {
+		return errors.New("prometheus only supports cumulative metric temporality")
+	}
+
+	if cfg.TraceSampler < ParentBasedAlwaysOnSampler || cfg.TraceSampler > ParentBasedTraceIDRatioSampler {
+		return errors.New("invalid trace sampler")
+	}
+
+	if cfg.TraceSamplerRatio == nil {
+		ratio := 1.0
+		cfg.TraceSamplerRatio = &ratio
+	}
+
+	if ratio := *cfg.TraceSamplerRatio; ratio < 0 || ratio > 1 {
+		return errors.New("invalid trace sampler ratio")
+	}
+
+	if cfg.MetricCardinalityLimit < 0 {
+		return errors.New("invalid metric cardinality limit")
+	}
+
+	if err := ValidateBoundaries(cfg.HistogramBoundaries); err != nil {
+		return fmt.Errorf("invalid histogram boundaries: %w", err)
+	}
+
+	for _, view := range cfg.Views {
+		if err := view.validate(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.ExponentialHistogramMaxScale == nil {
+		maxScale := int32(DefaultExponentialHistogramMaxScale)
+		cfg.ExponentialHistogramMaxScale = &maxScale
+	}
+
+	if maxScale := *cfg.ExponentialHistogramMaxScale; maxScale < -10 || maxScale > 20 {
+		return errors.New("invalid exponential histogram max scale")
+	}
+
+	if cfg.ExponentialHistogramMaxSize == 0 {
+		cfg.ExponentialHistogramMaxSize = DefaultExponentialHistogramMaxSize
+	}
+
+	if cfg.ExponentialHistogramMaxSize < 2 {
+		return errors.New("invalid exponential histogram max size")
+	}
+
+	if cfg.LogQueueSize <= 0 {
+		cfg.LogQueueSize = 1024
+	}
+
+	if cfg.LogWorkers <= 0 {
+		cfg.LogWorkers = 1
+	}
+
+	if cfg.ExitHook == nil {
+		cfg.ExitHook = os.Exit
 	}
 
 	if cfg.Port == "" {
@@ -57,6 +195,8 @@ func (cfg *Config) Ensure() error {
 		return fmt.Errorf("invalid hostname: %w", err)
 	}
 
+	cfg.resource = newResource(context.Background(), *cfg)
+
 	return nil
 }
 
@@ -64,6 +204,50 @@ func (cfg Config) GetHostname() string {
 	return cfg.hostname
 }
 
+// GetResource returns the resource describing the service instance, built by Ensure. Configs passed to the
+// constructors without Ensure build it on every call instead.
+func (cfg Config) GetResource() *resource.Resource {
+	if cfg.resource == nil {
+		if cfg.hostname == "" {
+			cfg.hostname, _ = os.Hostname()
+		}
+		return newResource(context.Background(), cfg)
+	}
+	return cfg.resource
+}
+
+// GetFlushInterval returns FlushInterval, or its default when unset.
+func (cfg Config) GetFlushInterval() time.Duration {
+	if cfg.FlushInterval <= 0 {
+		return DefaultFlushInterval
+	}
+	return cfg.FlushInterval
+}
+
+// GetTimeout returns Timeout, or its default when unset.
+func (cfg Config) GetTimeout() time.Duration {
+	if cfg.Timeout <= 0 {
+		return DefaultTimeout
+	}
+	return cfg.Timeout
+}
+
+// GetExponentialHistogramMaxScale returns ExponentialHistogramMaxScale, or its default when unset.
+func (cfg Config) GetExponentialHistogramMaxScale() int32 {
+	if cfg.ExponentialHistogramMaxScale == nil {
+		return DefaultExponentialHistogramMaxScale
+	}
+	return *cfg.ExponentialHistogramMaxScale
+}
+
+// GetTraceSamplerRatio returns TraceSamplerRatio, or its default when unset.
+func (cfg Config) GetTraceSamplerRatio() float64 {
+	if cfg.TraceSamplerRatio == nil {
+		return 1
+	}
+	return *cfg.TraceSamplerRatio
+}
+
 func (cfg Config) GetSearchIndex() string {
 	if cfg.Mode == Development {
 		return cfg.SearchIndex
@@ -0,0 +1,223 @@
+package config
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+)
+
+const (
+	// podInfoDir is where the downward API volume is expected, with the "name", "namespace", "uid" and "labels" items
+	podInfoDir = "etc/podinfo"
+	// serviceAccountNamespace is mounted in every pod with a service account token
+	serviceAccountNamespace = "var/run/secrets/kubernetes.io/serviceaccount/namespace"
+	podLabelPrefix          = "k8s.pod.label."
+)
+
+var (
+	// containerIDPattern matches the 64 hexadecimal characters of a container ID, e.g. in "docker-<id>.scope"
+	containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+	// mountContainerIDPattern matches the container directory of the files the runtime mounts, e.g. /etc/hostname
+	mountContainerIDPattern = regexp.MustCompile(`/containers/([0-9a-f]{64})/`)
+)
+
+// envAttribute is a resource attribute read from the first of its environment variables that is set.
+type envAttribute struct {
+	key       attribute.Key
+	variables []string
+	// file under podInfoDir holding the attribute when none of the variables is set
+	file string
+}
+
+var kubernetesAttributes = []envAttribute{
+	{key: semconv.K8SPodNameKey, variables: []string{"K8S_POD_NAME", "POD_NAME"}, file: "name"},
+	{key: semconv.K8SNamespaceNameKey, variables: []string{"K8S_NAMESPACE_NAME", "POD_NAMESPACE"}, file: "namespace"},
+	{key: semconv.K8SPodUIDKey, variables: []string{"K8S_POD_UID", "POD_UID"}, file: "uid"},
+	{key: semconv.K8SNodeNameKey, variables: []string{"K8S_NODE_NAME", "NODE_NAME"}},
+	{key: semconv.K8SContainerNameKey, variables: []string{"K8S_CONTAINER_NAME", "CONTAINER_NAME"}},
+}
+
+// cloudProviders are matched in order, the first one with its region set wins.
+var cloudProviders = []struct {
+	provider attribute.KeyValue
+	region   string
+}{
+	{provider: semconv.CloudProviderAWS, region: "AWS_REGION"},
+	{provider: semconv.CloudProviderAWS, region: "AWS_DEFAULT_REGION"},
+	{provider: semconv.CloudProviderGCP, region: "GOOGLE_CLOUD_REGION"},
+	{provider: semconv.CloudProviderAzure, region: "REGION_NAME"},
+}
+
+// defaultDetectors run before the ResourceDetectors, reading the environment and files under root, "/" outside tests.
+func defaultDetectors(root, hostname string, getenv func(string) string) []resource.Detector {
+	return []resource.Detector{
+		kubernetesDetector{root: root, hostname: hostname, getenv: getenv},
+		containerDetector{root: root},
+		cloudDetector{getenv: getenv},
+	}
+}
+
+// kubernetesDetector reads the pod, namespace, node and labels exposed through the downward API, as environment
+// variables or files under /etc/podinfo. The pod name falls back to the hostname Ensure resolved, which Kubernetes sets
+// to it.
+type kubernetesDetector struct {
+	root     string
+	hostname string
+	getenv   func(string) string
+}
+
+func (detector kubernetesDetector) Detect(context.Context) (*resource.Resource, error) {
+	var attrs []attribute.KeyValue
+	for _, attr := range kubernetesAttributes {
+		if value := detector.lookup(attr); value != "" {
+			attrs = append(attrs, attr.key.String(value))
+		}
+	}
+
+	inCluster := detector.getenv("KUBERNETES_SERVICE_HOST") != ""
+	if !hasKey(attrs, semconv.K8SNamespaceNameKey) {
+		if namespace := readTrimmed(filepath.Join(detector.root, serviceAccountNamespace)); namespace != "" {
+			attrs = append(attrs, semconv.K8SNamespaceNameKey.String(namespace))
+			inCluster = true
+		}
+	}
+	if !hasKey(attrs, semconv.K8SPodNameKey) && inCluster && detector.hostname != "" {
+		attrs = append(attrs, semconv.K8SPodNameKey.String(detector.hostname))
+	}
+
+	labels, err := readPodLabels(filepath.Join(detector.root, podInfoDir, "labels"))
+	if err != nil {
+		return nil, err
+	}
+	attrs = append(attrs, labels...)
+
+	return resource.NewSchemaless(attrs...), nil
+}
+
+func (detector kubernetesDetector) lookup(attr envAttribute) string {
+	for _, variable := range attr.variables {
+		if value := detector.getenv(variable); value != "" {
+			return value
+		}
+	}
+	if attr.file == "" {
+		return ""
+	}
+	return readTrimmed(filepath.Join(detector.root, podInfoDir, attr.file))
+}
+
+// readPodLabels reads the downward API labels file, one key="value" line per label with Go-quoted values, as
+// k8s.pod.label.<key> attributes. A missing file has no labels.
+func readPodLabels(path string) ([]attribute.KeyValue, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var labels []attribute.KeyValue
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, quoted, found := strings.Cut(scanner.Text(), "=")
+		if !found {
+			continue
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			value = quoted
+		}
+		labels = append(labels, attribute.String(podLabelPrefix+key, value))
+	}
+	return labels, scanner.Err()
+}
+
+// containerDetector reads the ID of the container the process runs in from its cgroup, on cgroup v1, or from the
+// files the runtime mounts into the container, on cgroup v2 where the cgroup path is usually hidden.
+type containerDetector struct {
+	root string
+}
+
+func (detector containerDetector) Detect(context.Context) (*resource.Resource, error) {
+	id := lastMatch(filepath.Join(detector.root, "proc/self/cgroup"), containerIDPattern, 0)
+	if id == "" {
+		id = lastMatch(filepath.Join(detector.root, "proc/self/mountinfo"), mountContainerIDPattern, 1)
+	}
+	if id == "" {
+		return resource.Empty(), nil
+	}
+	return resource.NewSchemaless(semconv.ContainerIDKey.String(id)), nil
+}
+
+// lastMatch returns the given group of the last match of pattern in the file, or "" if it can't be read.
+func lastMatch(path string, pattern *regexp.Regexp, group int) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	var match string
+	for _, line := range strings.Split(string(content), "\n") {
+		if groups := pattern.FindStringSubmatch(line); groups != nil {
+			match = groups[group]
+		}
+	}
+	return match
+}
+
+// cloudDetector reads the cloud provider and region from the variables their platforms set, e.g. AWS_REGION. The
+// generic CLOUD_PROVIDER, CLOUD_REGION and CLOUD_AVAILABILITY_ZONE variables take precedence.
+type cloudDetector struct {
+	getenv func(string) string
+}
+
+func (detector cloudDetector) Detect(context.Context) (*resource.Resource, error) {
+	var attrs []attribute.KeyValue
+	for _, cloud := range cloudProviders {
+		if region := detector.getenv(cloud.region); region != "" {
+			attrs = append(attrs, cloud.provider, semconv.CloudRegionKey.String(region))
+			break
+		}
+	}
+
+	generic := []attribute.KeyValue{
+		semconv.CloudProviderKey.String(detector.getenv("CLOUD_PROVIDER")),
+		semconv.CloudRegionKey.String(detector.getenv("CLOUD_REGION")),
+		semconv.CloudAvailabilityZoneKey.String(detector.getenv("CLOUD_AVAILABILITY_ZONE")),
+	}
+	for _, attr := range generic {
+		if attr.Value.AsString() != "" {
+			attrs = append(attrs, attr)
+		}
+	}
+
+	// Later attributes of the same key win
+	return resource.NewSchemaless(attrs...), nil
+}
+
+func readTrimmed(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+func hasKey(attrs []attribute.KeyValue, key attribute.Key) bool {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
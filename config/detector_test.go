@@ -0,0 +1,141 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+func mapEnv(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+func detect(t *testing.T, detector resource.Detector) map[string]string {
+	res, err := detector.Detect(context.Background())
+	assert.NoError(t, err)
+
+	attrs := map[string]string{}
+	for _, attr := range res.Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	return attrs
+}
+
+func TestKubernetesDetector(t *testing.T) {
+	tests := []struct {
+		name string
+		root string
+		env  map[string]string
+		want map[string]string
+	}{
+		{
+			name: "downward API files",
+			root: "testdata/kubernetes",
+			want: map[string]string{
+				"k8s.pod.name":                    "checkout-7d9f8b6c5-x2v4q",
+				"k8s.pod.uid":                     "1f0e2d3c-4b5a-6978-8796-a5b4c3d2e1f0",
+				"k8s.namespace.name":              "payments",
+				"k8s.pod.label.app":               "checkout",
+				"k8s.pod.label.pod-template-hash": "7d9f8b6c5",
+				"k8s.pod.label.team":              `payments "core"`,
+			},
+		},
+		{
+			name: "environment variables win over files",
+			root: "testdata/kubernetes",
+			env:  map[string]string{"POD_NAME": "checkout-0", "K8S_NAMESPACE_NAME": "orders", "NODE_NAME": "node-1"},
+			want: map[string]string{
+				"k8s.pod.name":                    "checkout-0",
+				"k8s.pod.uid":                     "1f0e2d3c-4b5a-6978-8796-a5b4c3d2e1f0",
+				"k8s.namespace.name":              "orders",
+				"k8s.node.name":                   "node-1",
+				"k8s.pod.label.app":               "checkout",
+				"k8s.pod.label.pod-template-hash": "7d9f8b6c5",
+				"k8s.pod.label.team":              `payments "core"`,
+			},
+		},
+		{
+			name: "pod name from the hostname in a cluster",
+			root: t.TempDir(),
+			env:  map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1"},
+			want: map[string]string{"k8s.pod.name": "checkout-5"},
+		},
+		{
+			name: "outside Kubernetes",
+			root: t.TempDir(),
+			want: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := kubernetesDetector{root: tt.root, hostname: "checkout-5", getenv: mapEnv(tt.env)}
+			assert.Equal(t, tt.want, detect(t, detector))
+		})
+	}
+}
+
+func TestContainerDetector(t *testing.T) {
+	tests := []struct {
+		name string
+		root string
+		want map[string]string
+	}{
+		{
+			name: "cgroup v1",
+			root: "testdata/kubernetes",
+			want: map[string]string{"container.id": "3f1b2c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e7f809"},
+		},
+		{
+			name: "cgroup v2 mountinfo",
+			root: "testdata/docker",
+			want: map[string]string{"container.id": "9c8b7a6f5e4d3c2b1a0f9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b"},
+		},
+		{
+			name: "not in a container",
+			root: t.TempDir(),
+			want: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detect(t, containerDetector{root: tt.root}))
+		})
+	}
+}
+
+func TestCloudDetector(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want map[string]string
+	}{
+		{
+			name: "aws",
+			env:  map[string]string{"AWS_DEFAULT_REGION": "us-east-1", "AWS_REGION": "eu-west-1"},
+			want: map[string]string{"cloud.provider": "aws", "cloud.region": "eu-west-1"},
+		},
+		{
+			name: "availability zone",
+			env:  map[string]string{"GOOGLE_CLOUD_REGION": "europe-west1", "CLOUD_AVAILABILITY_ZONE": "europe-west1-b"},
+			want: map[string]string{"cloud.provider": "gcp", "cloud.region": "europe-west1", "cloud.availability_zone": "europe-west1-b"},
+		},
+		{
+			name: "generic region overrides the provider's",
+			env:  map[string]string{"AWS_REGION": "eu-west-1", "CLOUD_REGION": "eu-west-2"},
+			want: map[string]string{"cloud.provider": "aws", "cloud.region": "eu-west-2"},
+		},
+		{
+			name: "no cloud",
+			want: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detect(t, cloudDetector{getenv: mapEnv(tt.env)}))
+		})
+	}
+}
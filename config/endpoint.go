@@ -0,0 +1,28 @@
+package config
+
+import "fmt"
+
+const (
+	prodEndpoint  = "otel-collector.garden.internal"
+	devEndpoint   = "localhost:4317"
+	debugEndpoint = "localhost:4317"
+)
+
+// GetEndpoint returns the OTLP gRPC collector endpoint used by every signal for the configured Mode. It returns an
+// empty string for Noop and Local, which never talk to a collector. Endpoint replaces the mode's endpoint when set.
+func (cfg Config) GetEndpoint() string {
+	if cfg.Endpoint != "" && cfg.Mode != Noop && cfg.Mode != Local {
+		return cfg.Endpoint
+	}
+
+	switch cfg.Mode {
+	case Debug:
+		return debugEndpoint
+	case Development:
+		return devEndpoint
+	case Production:
+		return fmt.Sprintf("%s:%s", prodEndpoint, cfg.Port)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,39 @@
+package config
+
+// MetricExporter is an enum for describing how metrics leave the process. The following exporters are allowed:
+//  1. OTLPExporter: pushes metrics to the mode's collector every FlushInterval, or to stdout in Local mode.
+//  2. PrometheusExporter: only serves metrics to Prometheus scrapes, through the meter's PrometheusHandler.
+//  3. OTLPAndPrometheusExporter: pushes metrics and serves them to Prometheus scrapes.
+type MetricExporter int8
+
+const (
+	OTLPExporter MetricExporter = iota
+	PrometheusExporter
+	OTLPAndPrometheusExporter
+)
+
+// ServesPrometheus reports whether metrics are served to Prometheus scrapes.
+func (exporter MetricExporter) ServesPrometheus() bool {
+	return exporter == PrometheusExporter || exporter == OTLPAndPrometheusExporter
+}
+
+// PushesOTLP reports whether metrics are pushed by the OTLP or stdout exporter.
+func (exporter MetricExporter) PushesOTLP() bool {
+	return exporter == OTLPExporter || exporter == OTLPAndPrometheusExporter
+}
+
+var metricExporters = map[string]MetricExporter{
+	"otlp":                OTLPExporter,
+	"prometheus":          PrometheusExporter,
+	"otlp_and_prometheus": OTLPAndPrometheusExporter,
+}
+
+// UnmarshalText parses the exporter from its name in snake case, e.g. "otlp_and_prometheus".
+func (exporter *MetricExporter) UnmarshalText(text []byte) error {
+	parsed, err := parseEnum("metric exporter", string(text), metricExporters)
+	if err != nil {
+		return err
+	}
+	*exporter = parsed
+	return nil
+}
@@ -0,0 +1,292 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// envPrefix starts our own variables, like garden_STACK, followed by the file key in upper case
+	envPrefix = "garden_"
+	// defaultOTLPPort is the OTLP gRPC port, used when OTEL_EXPORTER_OTLP_ENDPOINT has none
+	defaultOTLPPort = "4317"
+)
+
+// envVariable sets a Config field from the value of an environment variable, when it is set and not empty.
+type envVariable struct {
+	name string
+	set  func(cfg *Config, value string) error
+}
+
+// envVariables are applied in order, so our own variables override the standard OpenTelemetry ones.
+var envVariables = []envVariable{
+	{name: "OTEL_RESOURCE_ATTRIBUTES", set: setServiceFromResourceAttributes},
+	{name: "OTEL_SERVICE_NAME", set: parsedVar(parseString, func(cfg *Config) *string { return &cfg.Service.Name })},
+	{name: "OTEL_EXPORTER_OTLP_PROTOCOL", set: checkOTLPProtocol},
+	{name: "OTEL_EXPORTER_OTLP_ENDPOINT", set: parsedVar(parseOTLPEndpoint, func(cfg *Config) *string { return &cfg.Endpoint })},
+	{name: "OTEL_EXPORTER_OTLP_HEADERS", set: mergedVar(func(cfg *Config) *map[string]string { return &cfg.OTLPHeaders })},
+	{name: "OTEL_EXPORTER_OTLP_TIMEOUT", set: parsedVar(parseMilliseconds, func(cfg *Config) *time.Duration { return &cfg.Timeout })},
+	{name: "OTEL_TRACES_SAMPLER", set: textVar(func(cfg *Config) encoding.TextUnmarshaler { return &cfg.TraceSampler })},
+	{name: "OTEL_TRACES_SAMPLER_ARG", set: optionalVar(parseFloat, func(cfg *Config) **float64 { return &cfg.TraceSamplerRatio })},
+
+	{name: envPrefix + "SERVICE_NAME", set: parsedVar(parseString, func(cfg *Config) *string { return &cfg.Service.Name })},
+	{name: envPrefix + "SERVICE_VERSION", set: parsedVar(parseString, func(cfg *Config) *string { return &cfg.Service.Version })},
+	{name: envPrefix + "MODE", set: textVar(func(cfg *Config) encoding.TextUnmarshaler { return &cfg.Mode })},
+	{name: envPrefix + "SEARCH_INDEX", set: parsedVar(parseString, func(cfg *Config) *string { return &cfg.SearchIndex })},
+	{name: envPrefix + "FLUSH_INTERVAL", set: parsedVar(time.ParseDuration, func(cfg *Config) *time.Duration { return &cfg.FlushInterval })},
+	{name: envPrefix + "TIMEOUT", set: parsedVar(time.ParseDuration, func(cfg *Config) *time.Duration { return &cfg.Timeout })},
+	{name: envPrefix + "PORT", set: parsedVar(parseString, func(cfg *Config) *string { return &cfg.Port })},
+	{name: envPrefix + "ENDPOINT", set: parsedVar(parseString, func(cfg *Config) *string { return &cfg.Endpoint })},
+	{name: envPrefix + "OTLP_HEADERS", set: mergedVar(func(cfg *Config) *map[string]string { return &cfg.OTLPHeaders })},
+	{name: envPrefix + "DEFAULT_FIELDS", set: setDefaultFields},
+	{name: envPrefix + "RECORD_ERROR_STACKTRACE", set: parsedVar(strconv.ParseBool, func(cfg *Config) *bool { return &cfg.RecordErrorStacktrace })},
+	{name: envPrefix + "LOG_QUEUE_SIZE", set: parsedVar(strconv.Atoi, func(cfg *Config) *int { return &cfg.LogQueueSize })},
+	{name: envPrefix + "LOG_WORKERS", set: parsedVar(strconv.Atoi, func(cfg *Config) *int { return &cfg.LogWorkers })},
+	{name: envPrefix + "LOG_OVERFLOW_POLICY", set: textVar(func(cfg *Config) encoding.TextUnmarshaler { return &cfg.LogOverflowPolicy })},
+	{name: envPrefix + "METRIC_EXPORTER", set: textVar(func(cfg *Config) encoding.TextUnmarshaler { return &cfg.MetricExporter })},
+	{name: envPrefix + "METRIC_TEMPORALITY", set: textVar(func(cfg *Config) encoding.TextUnmarshaler { return &cfg.MetricTemporality })},
+	{name: envPrefix + "METRIC_CARDINALITY_LIMIT", set: parsedVar(strconv.Atoi, func(cfg *Config) *int { return &cfg.MetricCardinalityLimit })},
+	{name: envPrefix + "METRIC_ATTRIBUTE_ALLOW_LIST", set: parsedVar(parseList, func(cfg *Config) *[]string { return &cfg.MetricAttributeAllowList })},
+	{name: envPrefix + "METRIC_ATTRIBUTE_DENY_LIST", set: parsedVar(parseList, func(cfg *Config) *[]string { return &cfg.MetricAttributeDenyList })},
+	{name: envPrefix + "HISTOGRAM_BOUNDARIES", set: parsedVar(parseFloats, func(cfg *Config) *[]float64 { return &cfg.HistogramBoundaries })},
+	{name: envPrefix + "EXPONENTIAL_HISTOGRAMS", set: parsedVar(strconv.ParseBool, func(cfg *Config) *bool { return &cfg.ExponentialHistograms })},
+	{name: envPrefix + "EXPONENTIAL_HISTOGRAM_MAX_SCALE", set: optionalVar(parseInt32, func(cfg *Config) **int32 { return &cfg.ExponentialHistogramMaxScale })},
+	{name: envPrefix + "EXPONENTIAL_HISTOGRAM_MAX_SIZE", set: parsedVar(parseInt32, func(cfg *Config) *int32 { return &cfg.ExponentialHistogramMaxSize })},
+	{name: envPrefix + "RUNTIME_METRICS", set: parsedVar(strconv.ParseBool, func(cfg *Config) *bool { return &cfg.RuntimeMetrics })},
+	{name: envPrefix + "PROCESS_METRICS", set: parsedVar(strconv.ParseBool, func(cfg *Config) *bool { return &cfg.ProcessMetrics })},
+	{name: envPrefix + "TRACE_SAMPLER", set: textVar(func(cfg *Config) encoding.TextUnmarshaler { return &cfg.TraceSampler })},
+	{name: envPrefix + "TRACE_SAMPLER_RATIO", set: optionalVar(parseFloat, func(cfg *Config) **float64 { return &cfg.TraceSamplerRatio })},
+	{name: envPrefix + "DISABLE_DEFAULT_DETECTORS", set: parsedVar(strconv.ParseBool, func(cfg *Config) *bool { return &cfg.DisableDefaultDetectors })},
+}
+
+// FromEnv reads a Config from the environment, to be passed to NewObservability. Later sources override earlier ones:
+//  1. The defaults Ensure sets for the fields left empty
+//  2. The file, for FromFile
+//  3. The standard OpenTelemetry variables: service.name and service.version of OTEL_RESOURCE_ATTRIBUTES, then
+//     OTEL_SERVICE_NAME, OTEL_EXPORTER_OTLP_ENDPOINT, OTEL_EXPORTER_OTLP_HEADERS, OTEL_EXPORTER_OTLP_TIMEOUT,
+//     OTEL_TRACES_SAMPLER and OTEL_TRACES_SAMPLER_ARG
+//  4. Our own variables, garden_ followed by the file key in upper case, e.g. garden_MODE=production or
+//     garden_FLUSH_INTERVAL=10s. Lists are comma separated and maps are comma separated key=value pairs
+//
+// The resource built from the Config carries the resulting service name and version, so garden_SERVICE_NAME wins over
+// OTEL_SERVICE_NAME there too. Empty variables are ignored. OTEL_EXPORTER_OTLP_PROTOCOL can only be grpc, the one protocol supported, and the
+// per-signal OTEL_EXPORTER_OTLP_* variables are not read, as every signal shares the endpoint.
+func FromEnv() (Config, error) {
+	var cfg Config
+	if err := applyEnv(&cfg, os.Getenv); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// FromFile reads a Config from the YAML file at path, then from the environment like FromEnv, whose variables override
+// the file. Keys are the field names in snake case, e.g. flush_interval: 10s or mode: production, and unknown keys are
+// rejected. ResourceDetectors and ExitHook can only be set in code.
+func FromFile(path string) (Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("error reading config file: %w", err)
+	}
+	defer file.Close()
+
+	var cfg Config
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	// An empty file leaves every field to the environment and the defaults
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+
+	if err := applyEnv(&cfg, os.Getenv); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func applyEnv(cfg *Config, getenv func(string) string) error {
+	for _, variable := range envVariables {
+		value := strings.TrimSpace(getenv(variable.name))
+		if value == "" {
+			continue
+		}
+		if err := variable.set(cfg, value); err != nil {
+			return fmt.Errorf("invalid %s: %w", variable.name, err)
+		}
+	}
+	return nil
+}
+
+func parsedVar[T any](parse func(string) (T, error), field func(cfg *Config) *T) func(cfg *Config, value string) error {
+	return func(cfg *Config, value string) error {
+		parsed, err := parse(value)
+		if err != nil {
+			return err
+		}
+		*field(cfg) = parsed
+		return nil
+	}
+}
+
+// optionalVar sets a field whose zero value is a valid setting, so that it is nil when unset.
+func optionalVar[T any](parse func(string) (T, error), field func(cfg *Config) **T) func(cfg *Config, value string) error {
+	return parsedVar(parse, func(cfg *Config) *T {
+		*field(cfg) = new(T)
+		return *field(cfg)
+	})
+}
+
+func textVar(field func(cfg *Config) encoding.TextUnmarshaler) func(cfg *Config, value string) error {
+	return func(cfg *Config, value string) error {
+		return field(cfg).UnmarshalText([]byte(value))
+	}
+}
+
+// mergedVar adds the pairs of the variable to the map, replacing the values of the keys it already has.
+func mergedVar(field func(cfg *Config) *map[string]string) func(cfg *Config, value string) error {
+	return func(cfg *Config, value string) error {
+		pairs, err := parsePairs(value)
+		if err != nil {
+			return err
+		}
+		values := field(cfg)
+		if *values == nil {
+			*values = make(map[string]string, len(pairs))
+		}
+		for key, value := range pairs {
+			(*values)[key] = value
+		}
+		return nil
+	}
+}
+
+func setDefaultFields(cfg *Config, value string) error {
+	if cfg.DefaultFields == nil {
+		cfg.DefaultFields = &map[string]string{}
+	}
+	return mergedVar(func(cfg *Config) *map[string]string { return cfg.DefaultFields })(cfg, value)
+}
+
+// setServiceFromResourceAttributes reads service.name and service.version, which Ensure requires. The resource reads
+// the other attributes itself.
+func setServiceFromResourceAttributes(cfg *Config, value string) error {
+	attrs, err := parsePairs(value)
+	if err != nil {
+		return err
+	}
+	if name := attrs["service.name"]; name != "" {
+		cfg.Service.Name = name
+	}
+	if version := attrs["service.version"]; version != "" {
+		cfg.Service.Version = version
+	}
+	return nil
+}
+
+func checkOTLPProtocol(_ *Config, value string) error {
+	if value != "grpc" {
+		return fmt.Errorf("unsupported protocol %q, only grpc is supported", value)
+	}
+	return nil
+}
+
+// parseOTLPEndpoint turns the URL of OTEL_EXPORTER_OTLP_ENDPOINT, e.g. http://collector:4317, into the host:port of
+// Endpoint. The connection to the collector is never encrypted, so https is rejected.
+func parseOTLPEndpoint(value string) (string, error) {
+	if !strings.Contains(value, "://") {
+		return value, nil
+	}
+
+	endpoint, err := url.Parse(value)
+	if err != nil {
+		return "", err
+	}
+	if endpoint.Scheme != "http" {
+		return "", fmt.Errorf("unsupported scheme %q, the collector connection is insecure", endpoint.Scheme)
+	}
+	if endpoint.Port() == "" {
+		return net.JoinHostPort(endpoint.Hostname(), defaultOTLPPort), nil
+	}
+	return endpoint.Host, nil
+}
+
+func parseMilliseconds(value string) (time.Duration, error) {
+	milliseconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(milliseconds) * time.Millisecond, nil
+}
+
+func parseString(value string) (string, error) {
+	return value, nil
+}
+
+func parseFloat(value string) (float64, error) {
+	return strconv.ParseFloat(value, 64)
+}
+
+func parseInt32(value string) (int32, error) {
+	parsed, err := strconv.ParseInt(value, 10, 32)
+	return int32(parsed), err
+}
+
+func parseList(value string) ([]string, error) {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list, nil
+}
+
+func parseFloats(value string) ([]float64, error) {
+	items, _ := parseList(value)
+	floats := make([]float64, 0, len(items))
+	for _, item := range items {
+		parsed, err := parseFloat(item)
+		if err != nil {
+			return nil, err
+		}
+		floats = append(floats, parsed)
+	}
+	return floats, nil
+}
+
+// parsePairs reads comma separated key=value pairs with percent-encoded values, the format of
+// OTEL_RESOURCE_ATTRIBUTES and OTEL_EXPORTER_OTLP_HEADERS.
+func parsePairs(value string) (map[string]string, error) {
+	items, _ := parseList(value)
+	pairs := make(map[string]string, len(items))
+	for _, item := range items {
+		key, encoded, found := strings.Cut(item, "=")
+		if !found || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid pair %q, expected key=value", item)
+		}
+		decoded, err := url.PathUnescape(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, err
+		}
+		pairs[strings.TrimSpace(key)] = decoded
+	}
+	return pairs, nil
+}
+
+// parseEnum returns the value of the given name, ignoring case and surrounding spaces.
+func parseEnum[T ~int8](kind, text string, values map[string]T) (T, error) {
+	value, ok := values[strings.ToLower(strings.TrimSpace(text))]
+	if !ok {
+		return 0, fmt.Errorf("unknown %s %q", kind, text)
+	}
+	return value, nil
+}
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+)
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    Mode
+		wantErr bool
+	}{
+		{text: "production", want: Production},
+		{text: " Development ", want: Development},
+		{text: "NOOP", want: Noop},
+		{text: "prod", wantErr: true},
+		{text: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			mode, err := ParseMode(tt.text)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, mode)
+		})
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    Config
+		wantErr bool
+	}{
+		{
+			name: "standard variables",
+			env: map[string]string{
+				"OTEL_RESOURCE_ATTRIBUTES":    "service.name=orders,service.version=2.0.0,team=payments",
+				"OTEL_SERVICE_NAME":           "checkout",
+				"OTEL_EXPORTER_OTLP_PROTOCOL": "grpc",
+				"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4317",
+				"OTEL_EXPORTER_OTLP_HEADERS":  "authorization=Bearer%20token,tenant=garden",
+				"OTEL_EXPORTER_OTLP_TIMEOUT":  "2500",
+				"OTEL_TRACES_SAMPLER":         "traceidratio",
+			},
+			want: Config{
+				Service:      Service{Name: "checkout", Version: "2.0.0"},
+				Endpoint:     "collector:4317",
+				OTLPHeaders:  map[string]string{"authorization": "Bearer token", "tenant": "garden"},
+				Timeout:      2500 * time.Millisecond,
+				TraceSampler: TraceIDRatioSampler,
+			},
+		},
+		{
+			name: "prefixed variables override standard ones",
+			env: map[string]string{
+				"OTEL_SERVICE_NAME":           "checkout",
+				"OTEL_EXPORTER_OTLP_ENDPOINT": "collector:4317",
+				"OTEL_TRACES_SAMPLER":         "always_on",
+				"OTEL_TRACES_SAMPLER_ARG":     "0.5",
+				"garden_SERVICE_NAME":         "orders",
+				"garden_SERVICE_VERSION":      "1.0.0",
+				"garden_MODE":                 "production",
+				"garden_ENDPOINT":             "otel-agent:4317",
+				"garden_FLUSH_INTERVAL":       "10s",
+				"garden_DEFAULT_FIELDS":       "team=payments",
+				"garden_LOG_OVERFLOW_POLICY":  "drop_newest",
+				"garden_METRIC_TEMPORALITY":   "low_memory",
+				"garden_HISTOGRAM_BOUNDARIES": "0.1, 1, 10",
+				"garden_PROCESS_METRICS":      "true",
+				"garden_TRACE_SAMPLER_RATIO":  "0.1",
+			},
+			want: Config{
+				Service:             Service{Name: "orders", Version: "1.0.0"},
+				Mode:                Production,
+				Endpoint:            "otel-agent:4317",
+				FlushInterval:       10 * time.Second,
+				DefaultFields:       &map[string]string{"team": "payments"},
+				LogOverflowPolicy:   DropNewest,
+				MetricTemporality:   LowMemory,
+				HistogramBoundaries: []float64{0.1, 1, 10},
+				ProcessMetrics:      true,
+				TraceSampler:        AlwaysOnSampler,
+				TraceSamplerRatio:   floatPointer(0.1),
+			},
+		},
+		{
+			name: "zero max scale",
+			env:  map[string]string{"garden_EXPONENTIAL_HISTOGRAM_MAX_SCALE": "0"},
+			want: Config{ExponentialHistogramMaxScale: new(int32)},
+		},
+		{
+			name: "zero sampler ratio",
+			env:  map[string]string{"OTEL_TRACES_SAMPLER_ARG": "0"},
+			want: Config{TraceSamplerRatio: new(float64)},
+		},
+		{
+			name: "endpoint without port",
+			env:  map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector"},
+			want: Config{Endpoint: "collector:4317"},
+		},
+		{
+			name: "empty variables are ignored",
+			env:  map[string]string{"garden_MODE": " ", "OTEL_SERVICE_NAME": ""},
+			want: Config{},
+		},
+		{
+			name:    "unsupported protocol",
+			env:     map[string]string{"OTEL_EXPORTER_OTLP_PROTOCOL": "http/protobuf"},
+			wantErr: true,
+		},
+		{
+			name:    "encrypted endpoint",
+			env:     map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "https://collector:4317"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown mode",
+			env:     map[string]string{"garden_MODE": "staging"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid duration",
+			env:     map[string]string{"garden_FLUSH_INTERVAL": "10"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			err := applyEnv(&cfg, mapEnv(tt.env))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, cfg)
+		})
+	}
+}
+
+func TestFromFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		env     map[string]string
+		check   func(t *testing.T, cfg Config)
+		wantErr bool
+	}{
+		{
+			name: "file",
+			path: "testdata/config.yaml",
+			check: func(t *testing.T, cfg Config) {
+				assert.Equal(t, Service{Name: "checkout", Version: "1.4.0"}, cfg.Service)
+				assert.Equal(t, Production, cfg.Mode)
+				assert.Equal(t, "4317", cfg.Port)
+				assert.Equal(t, 10*time.Second, cfg.FlushInterval)
+				assert.Equal(t, 5*time.Second, cfg.Timeout)
+				assert.Equal(t, map[string]string{"authorization": "Bearer file-token"}, cfg.OTLPHeaders)
+				assert.Equal(t, &map[string]string{"team": "payments"}, cfg.DefaultFields)
+				assert.Equal(t, DropOldest, cfg.LogOverflowPolicy)
+				assert.Equal(t, OTLPAndPrometheusExporter, cfg.MetricExporter)
+				assert.Equal(t, []float64{0.1, 0.5, 1}, cfg.HistogramBoundaries)
+				assert.Equal(t, []View{{Pattern: "http.server.*", DropAttributes: []string{"http.target"}, Aggregation: ExponentialHistogramAggregation}}, cfg.Views)
+				assert.True(t, cfg.RuntimeMetrics)
+				assert.Equal(t, ParentBasedTraceIDRatioSampler, cfg.TraceSampler)
+				assert.Equal(t, floatPointer(0.25), cfg.TraceSamplerRatio)
+				assert.NoError(t, cfg.Ensure())
+			},
+		},
+		{
+			name: "environment overrides the file",
+			path: "testdata/config.yaml",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_HEADERS": "authorization=Bearer env-token",
+				"garden_MODE":                "development",
+				"garden_FLUSH_INTERVAL":      "1m",
+			},
+			check: func(t *testing.T, cfg Config) {
+				assert.Equal(t, Development, cfg.Mode)
+				assert.Equal(t, time.Minute, cfg.FlushInterval)
+				assert.Equal(t, map[string]string{"authorization": "Bearer env-token"}, cfg.OTLPHeaders)
+				assert.Equal(t, 5*time.Second, cfg.Timeout)
+			},
+		},
+		{
+			name: "ratio sampler without ratio",
+			path: "testdata/sampler.yaml",
+			check: func(t *testing.T, cfg Config) {
+				assert.Equal(t, TraceIDRatioSampler, cfg.TraceSampler)
+				assert.Nil(t, cfg.TraceSamplerRatio)
+				assert.NoError(t, cfg.Ensure())
+				assert.Equal(t, floatPointer(1), cfg.TraceSamplerRatio, "samples every trace by default, like the SDK")
+			},
+		},
+		{
+			name:    "unknown key",
+			path:    "testdata/unknown.yaml",
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			path:    "testdata/missing.yaml",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Empty variables are ignored, which hides those of the environment running the tests
+			for _, variable := range envVariables {
+				t.Setenv(variable.name, "")
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := FromFile(tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestFromEnv_ServiceResource(t *testing.T) {
+	for _, variable := range envVariables {
+		t.Setenv(variable.name, "")
+	}
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "service.name=orders,service.version=2.0.0,team=payments")
+	t.Setenv("OTEL_SERVICE_NAME", "checkout")
+	t.Setenv("garden_SERVICE_NAME", "billing")
+	t.Setenv("garden_SERVICE_VERSION", "1.0.0")
+
+	cfg, err := FromEnv()
+	assert.NoError(t, err)
+	assert.NoError(t, cfg.Ensure())
+	assert.Equal(t, Service{Name: "billing", Version: "1.0.0"}, cfg.Service)
+
+	attrs := cfg.GetResource().Set()
+	name, _ := attrs.Value(semconv.ServiceNameKey)
+	assert.Equal(t, "billing", name.AsString(), "the resource agrees with the config")
+	version, _ := attrs.Value(semconv.ServiceVersionKey)
+	assert.Equal(t, "1.0.0", version.AsString())
+	team, _ := attrs.Value("team")
+	assert.Equal(t, "payments", team.AsString(), "other resource attributes are kept")
+}
+
+func floatPointer(value float64) *float64 {
+	return &value
+}
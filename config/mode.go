@@ -18,3 +18,26 @@ const (
 	Development
 	Production
 )
+
+var modes = map[string]Mode{
+	"noop":        Noop,
+	"local":       Local,
+	"debug":       Debug,
+	"development": Development,
+	"production":  Production,
+}
+
+// ParseMode returns the Mode of the given name, e.g. "production", ignoring case.
+func ParseMode(text string) (Mode, error) {
+	return parseEnum("mode", text, modes)
+}
+
+// UnmarshalText parses the mode with ParseMode, e.g. when reading a config file.
+func (mode *Mode) UnmarshalText(text []byte) error {
+	parsed, err := ParseMode(string(text))
+	if err != nil {
+		return err
+	}
+	*mode = parsed
+	return nil
+}
@@ -0,0 +1,30 @@
+package config
+
+// OverflowPolicy is an enum for describing what the logger does when its queue is full. The following policies are
+// allowed:
+//  1. Block: waits until the queue has room for the entry. No entry is lost, but a slow exporter slows the caller down.
+//  2. DropNewest: discards the entry being logged and keeps the queue as it is.
+//  3. DropOldest: discards the oldest queued entry to make room for the one being logged.
+type OverflowPolicy int8
+
+const (
+	Block OverflowPolicy = iota
+	DropNewest
+	DropOldest
+)
+
+var overflowPolicies = map[string]OverflowPolicy{
+	"block":       Block,
+	"drop_newest": DropNewest,
+	"drop_oldest": DropOldest,
+}
+
+// UnmarshalText parses the policy from its name in snake case, e.g. "drop_oldest".
+func (policy *OverflowPolicy) UnmarshalText(text []byte) error {
+	parsed, err := parseEnum("log overflow policy", string(text), overflowPolicies)
+	if err != nil {
+		return err
+	}
+	*policy = parsed
+	return nil
+}
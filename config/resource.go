@@ -0,0 +1,55 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/garden/observability-commons/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+)
+
+// newResource builds the resource describing the service instance, shared by logs, metrics and traces. Attributes of
+// later sources override those of earlier ones:
+//  1. host.name, the build information and telemetry.sdk.*
+//  2. The Kubernetes, container and cloud detectors, unless DisableDefaultDetectors, then ResourceDetectors in order
+//  3. OTEL_RESOURCE_ATTRIBUTES, then OTEL_SERVICE_NAME
+//  4. service.name and service.version from Service, when set
+//
+// Service is the resolved identity of the service: FromEnv already weighs OTEL_SERVICE_NAME and
+// OTEL_RESOURCE_ATTRIBUTES against our own variables, so the resource never disagrees with the config. Detectors that
+// fail are reported to the OpenTelemetry error handler, keeping the attributes of the others.
+func newResource(ctx context.Context, cfg Config) *resource.Resource {
+	attrs := append([]attribute.KeyValue{attribute.String("host.name", cfg.hostname)}, util.ReadBuildInfo().Attributes()...)
+
+	var service []attribute.KeyValue
+	if cfg.Service.Name != "" {
+		service = append(service, semconv.ServiceNameKey.String(cfg.Service.Name))
+	}
+	if cfg.Service.Version != "" {
+		service = append(service, semconv.ServiceVersionKey.String(cfg.Service.Version))
+	}
+
+	detectors := cfg.ResourceDetectors
+	if !cfg.DisableDefaultDetectors {
+		detectors = append(defaultDetectors("/", cfg.hostname, os.Getenv), detectors...)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attrs...),
+		resource.WithTelemetrySDK(),
+		resource.WithDetectors(detectors...),
+		resource.WithFromEnv(),
+		resource.WithAttributes(service...),
+	)
+	if err != nil {
+		otel.Handle(fmt.Errorf("error detecting resource: %w", err))
+	}
+	if res == nil {
+		return resource.NewSchemaless(append(attrs, service...)...)
+	}
+	return res
+}
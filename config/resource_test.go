@@ -0,0 +1,120 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+)
+
+type staticDetector []attribute.KeyValue
+
+func (detector staticDetector) Detect(context.Context) (*resource.Resource, error) {
+	return resource.NewSchemaless(detector...), nil
+}
+
+type failingDetector struct{}
+
+func (failingDetector) Detect(context.Context) (*resource.Resource, error) {
+	return nil, errors.New("no metadata server")
+}
+
+func TestConfig_GetResource(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		detectors []resource.Detector
+		want      map[string]string
+	}{
+		{
+			name: "config",
+			want: map[string]string{"service.name": "checkout", "service.version": "1.0.0", "telemetry.sdk.language": "go"},
+		},
+		{
+			name:      "detectors override the host",
+			detectors: []resource.Detector{staticDetector{attribute.String("host.name", "node-1"), attribute.String("k8s.pod.name", "checkout-1")}},
+			want:      map[string]string{"host.name": "node-1", "k8s.pod.name": "checkout-1"},
+		},
+		{
+			name:      "the config's service overrides detectors",
+			detectors: []resource.Detector{staticDetector{attribute.String("service.version", "1.0.1")}},
+			want:      map[string]string{"service.name": "checkout", "service.version": "1.0.0"},
+		},
+		{
+			name:      "environment overrides detectors",
+			env:       map[string]string{"OTEL_RESOURCE_ATTRIBUTES": "k8s.pod.name=checkout-2,team=payments"},
+			detectors: []resource.Detector{staticDetector{attribute.String("k8s.pod.name", "checkout-1")}},
+			want:      map[string]string{"service.name": "checkout", "k8s.pod.name": "checkout-2", "team": "payments"},
+		},
+		{
+			name: "the config's service overrides the environment",
+			env:  map[string]string{"OTEL_RESOURCE_ATTRIBUTES": "service.name=orders,service.version=2.0.0", "OTEL_SERVICE_NAME": "payments"},
+			want: map[string]string{"service.name": "checkout", "service.version": "1.0.0"},
+		},
+		{
+			name:      "failing detectors are skipped",
+			detectors: []resource.Detector{failingDetector{}, staticDetector{attribute.String("k8s.pod.name", "checkout-1")}},
+			want:      map[string]string{"service.name": "checkout", "k8s.pod.name": "checkout-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "")
+			t.Setenv("OTEL_SERVICE_NAME", "")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg := Config{
+				Service:           Service{Name: "checkout", Version: "1.0.0"},
+				ResourceDetectors: tt.detectors,
+			}
+			assert.NoError(t, cfg.Ensure())
+
+			attrs := map[string]string{}
+			for _, attr := range cfg.GetResource().Attributes() {
+				attrs[string(attr.Key)] = attr.Value.Emit()
+			}
+			if _, ok := tt.want["host.name"]; !ok {
+				tt.want["host.name"] = cfg.GetHostname()
+			}
+			for key, value := range tt.want {
+				assert.Equal(t, value, attrs[key], key)
+			}
+		})
+	}
+}
+
+func TestConfig_GetResource_DisableDefaultDetectors(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv("K8S_POD_NAME", "")
+	t.Setenv("POD_NAME", "")
+
+	for _, disabled := range []bool{false, true} {
+		cfg := Config{Service: Service{Name: "checkout", Version: "1.0.0"}, DisableDefaultDetectors: disabled}
+		assert.NoError(t, cfg.Ensure())
+
+		podName, detected := cfg.GetResource().Set().Value(semconv.K8SPodNameKey)
+		assert.Equal(t, !disabled, detected)
+		if detected {
+			assert.Equal(t, cfg.GetHostname(), podName.AsString(), "the pod name falls back to the resolved hostname")
+		}
+	}
+}
+
+func TestConfig_GetResource_WithoutEnsure(t *testing.T) {
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "")
+	t.Setenv("OTEL_SERVICE_NAME", "")
+
+	cfg := Config{Service: Service{Name: "checkout", Version: "1.0.0"}}
+
+	attrs := cfg.GetResource().Set()
+	name, _ := attrs.Value(semconv.ServiceNameKey)
+	assert.Equal(t, "checkout", name.AsString())
+	hostname, _ := attrs.Value(semconv.HostNameKey)
+	assert.NotEmpty(t, hostname.AsString())
+}
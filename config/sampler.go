@@ -0,0 +1,45 @@
+package config
+
+// Sampler is an enum for describing which traces are recorded and exported, named after the OTEL_TRACES_SAMPLER
+// values. The following samplers are allowed:
+//  1. ParentBasedAlwaysOnSampler: follows the decision of the parent span, sampling every root span.
+//  2. AlwaysOnSampler: samples every span.
+//  3. AlwaysOffSampler: samples no span.
+//  4. TraceIDRatioSampler: samples the fraction TraceSamplerRatio of the traces, picked by their ID.
+//  5. ParentBasedAlwaysOffSampler: follows the decision of the parent span, sampling no root span.
+//  6. ParentBasedTraceIDRatioSampler: follows the decision of the parent span, sampling root spans like
+//     TraceIDRatioSampler.
+type Sampler int8
+
+const (
+	ParentBasedAlwaysOnSampler Sampler = iota
+	AlwaysOnSampler
+	AlwaysOffSampler
+	TraceIDRatioSampler
+	ParentBasedAlwaysOffSampler
+	ParentBasedTraceIDRatioSampler
+)
+
+var samplers = map[string]Sampler{
+	"parentbased_always_on":    ParentBasedAlwaysOnSampler,
+	"always_on":                AlwaysOnSampler,
+	"always_off":               AlwaysOffSampler,
+	"traceidratio":             TraceIDRatioSampler,
+	"parentbased_always_off":   ParentBasedAlwaysOffSampler,
+	"parentbased_traceidratio": ParentBasedTraceIDRatioSampler,
+}
+
+// UnmarshalText parses the sampler from its OTEL_TRACES_SAMPLER name, e.g. "parentbased_traceidratio".
+func (sampler *Sampler) UnmarshalText(text []byte) error {
+	parsed, err := parseEnum("trace sampler", string(text), samplers)
+	if err != nil {
+		return err
+	}
+	*sampler = parsed
+	return nil
+}
+
+// UsesRatio reports whether the sampler samples root spans by TraceSamplerRatio.
+func (sampler Sampler) UsesRatio() bool {
+	return sampler == TraceIDRatioSampler || sampler == ParentBasedTraceIDRatioSampler
+}
@@ -0,0 +1,33 @@
+package config
+
+// Temporality is an enum for describing whether metrics report totals since the process started or changes since the
+// last export. The following temporalities are allowed:
+//  1. Cumulative: every export reports totals since the start. Every series ever recorded is kept in memory and
+//     exported, even once it stops being updated.
+//  2. Delta: every export reports the changes since the previous one. Series not updated since the last export are
+//     neither exported nor kept in memory.
+//  3. LowMemory: picks per instrument kind, deltas for counters and histograms, cumulative for up-down counters and
+//     asynchronous instruments, which are totals by nature. Series not updated since the last export are not exported.
+type Temporality int8
+
+const (
+	Cumulative Temporality = iota
+	Delta
+	LowMemory
+)
+
+var temporalities = map[string]Temporality{
+	"cumulative": Cumulative,
+	"delta":      Delta,
+	"low_memory": LowMemory,
+}
+
+// UnmarshalText parses the temporality from its name in snake case, e.g. "low_memory".
+func (temporality *Temporality) UnmarshalText(text []byte) error {
+	parsed, err := parseEnum("metric temporality", string(text), temporalities)
+	if err != nil {
+		return err
+	}
+	*temporality = parsed
+	return nil
+}
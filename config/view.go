@@ -0,0 +1,93 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+)
+
+// Aggregation is an enum for describing how a view aggregates the measurements of an instrument. The following
+// aggregations are allowed:
+//  1. DefaultAggregation: keeps the instrument's own aggregation, sums for counters, last value for gauges and
+//     histograms for histograms.
+//  2. SumAggregation: reports the sum of the measurements.
+//  3. LastValueAggregation: reports the last measurement.
+//  4. HistogramAggregation: reports the distribution of the measurements over the view's Boundaries.
+//  5. DropAggregation: discards the measurements, the instrument is not exported.
+//  6. ExponentialHistogramAggregation: reports the distribution of the measurements over base-2 exponential buckets,
+//     sized by Config.ExponentialHistogramMaxScale and Config.ExponentialHistogramMaxSize.
+type Aggregation int8
+
+const (
+	DefaultAggregation Aggregation = iota
+	SumAggregation
+	LastValueAggregation
+	HistogramAggregation
+	DropAggregation
+	ExponentialHistogramAggregation
+)
+
+var aggregations = map[string]Aggregation{
+	"default":               DefaultAggregation,
+	"sum":                   SumAggregation,
+	"last_value":            LastValueAggregation,
+	"histogram":             HistogramAggregation,
+	"drop":                  DropAggregation,
+	"exponential_histogram": ExponentialHistogramAggregation,
+}
+
+// UnmarshalText parses the aggregation from its name in snake case, e.g. "exponential_histogram".
+func (aggregation *Aggregation) UnmarshalText(text []byte) error {
+	parsed, err := parseEnum("view aggregation", string(text), aggregations)
+	if err != nil {
+		return err
+	}
+	*aggregation = parsed
+	return nil
+}
+
+// View changes how the instruments whose name matches Pattern are exported. When several views match an instrument,
+// the first one wins.
+type View struct {
+	// Pattern matches instrument names with the path.Match syntax, e.g. "http.server.*"
+	Pattern string `yaml:"pattern"`
+	// Name renames the matched instruments when set
+	Name string `yaml:"name"`
+	// DropAttributes removes the given attribute keys from every measurement
+	DropAttributes []string    `yaml:"drop_attributes"`
+	Aggregation    Aggregation `yaml:"aggregation"`
+	// Boundaries of the histogram buckets, overriding the metric's own and the default ones
+	Boundaries []float64 `yaml:"boundaries"`
+}
+
+// Matches reports whether the view applies to the instrument of the given name.
+func (view View) Matches(name string) bool {
+	matched, err := path.Match(view.Pattern, name)
+	return err == nil && matched
+}
+
+func (view View) validate() error {
+	if _, err := path.Match(view.Pattern, ""); err != nil || view.Pattern == "" {
+		return fmt.Errorf("invalid view pattern %q", view.Pattern)
+	}
+
+	if view.Aggregation < DefaultAggregation || view.Aggregation > ExponentialHistogramAggregation {
+		return fmt.Errorf("invalid view aggregation for pattern %q", view.Pattern)
+	}
+
+	if err := ValidateBoundaries(view.Boundaries); err != nil {
+		return fmt.Errorf("invalid view boundaries for pattern %q: %w", view.Pattern, err)
+	}
+
+	return nil
+}
+
+// ValidateBoundaries checks histogram bucket boundaries are strictly increasing.
+func ValidateBoundaries(boundaries []float64) error {
+	for i := 1; i < len(boundaries); i++ {
+		if boundaries[i] <= boundaries[i-1] {
+			return errors.New("boundaries must be strictly increasing")
+		}
+	}
+	return nil
+}
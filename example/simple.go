@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"sync/atomic"
 	"time"
 
 	obs "github.com/garden/observability-commons"
@@ -90,19 +91,41 @@ func main() {
 		"region": "us-east-1",
 	})
 
-	// Start background metrics collection
-	go sendSystemMetrics(observabilityClient)
+	// Pull-style metrics are sampled at each collection instead of being pushed from a ticker
+	var activeOrders, processedOrders int64
+	fields := map[string]string{
+		"service": "checkout",
+	}
+	unregisterActive, err := observabilityClient.RegisterGaugeFunc("active_orders", func(context.Context) int64 {
+		return atomic.LoadInt64(&activeOrders)
+	}, fields)
+	if err != nil {
+		panic(err)
+	}
+	defer unregisterActive()
+
+	unregisterProcessed, err := observabilityClient.RegisterCounterFunc("orders_processed_total", func(context.Context) int64 {
+		return atomic.LoadInt64(&processedOrders)
+	}, fields)
+	if err != nil {
+		panic(err)
+	}
+	defer unregisterProcessed()
+
+	// Start background order processing
+	go processOrders(observabilityClient, &activeOrders, &processedOrders)
 
 	fmt.Println("Observability example running. Press Enter to exit...")
 	input := bufio.NewScanner(os.Stdin)
 	input.Scan()
 }
 
-func sendSystemMetrics(observabilityClient obs.Observability) {
+func processOrders(observabilityClient obs.Observability, activeOrders, processedOrders *int64) {
 	for {
 		delay := randomDelay(1, 1000)
 
 		ctx := context.Background()
+		atomic.StoreInt64(activeOrders, int64(delay/100))
 
 		// Send histogram metric
 		fmt.Printf("Sending histogram metric: %s = %f\n", "processing_delay_ms", delay)
@@ -110,19 +133,8 @@ func sendSystemMetrics(observabilityClient obs.Observability) {
 			"service": "checkout",
 		})
 
-		// Send gauge metric
-		fmt.Printf("Sending gauge metric: %s = %v\n", "active_orders", int64(delay/100))
-		observabilityClient.SystemMetricGauge(ctx, "active_orders", int64(delay/100), map[string]string{
-			"service": "checkout",
-		})
-
-		// Send counter metric
-		fmt.Printf("Sending counter metric: %s = %v\n", "orders_processed_total", 1)
-		observabilityClient.SystemMetricCounter(ctx, "orders_processed_total", 1, map[string]string{
-			"service": "checkout",
-		})
-
 		time.Sleep(time.Duration(delay) * time.Millisecond)
+		atomic.AddInt64(processedOrders, 1)
 	}
 }
 
@@ -0,0 +1,340 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/garden/observability-commons/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	maxExportBatchSize = 512
+)
+
+// otlpExporter buffers log records and ships them in batches to the collector's OTLP logs pipeline. A batch is sent
+// whenever it reaches maxExportBatchSize records, every cfg.FlushInterval and on Flush/Shutdown. Configs that weren't
+// ensured use the default interval and timeout.
+type otlpExporter struct {
+	client   collogspb.LogsServiceClient
+	conn     *grpc.ClientConn
+	resource *resourcepb.Resource
+	headers  metadata.MD
+	timeout  time.Duration
+
+	mu      sync.Mutex
+	records []*logspb.LogRecord
+
+	exportMu sync.Mutex
+
+	flush    chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newOTLPExporter(cfg config.Config) (*otlpExporter, error) {
+	conn, err := grpc.Dial(cfg.GetEndpoint(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("error creating otlp log client: %w", err)
+	}
+
+	exporter := newOTLPExporterWithClient(cfg, collogspb.NewLogsServiceClient(conn))
+	exporter.conn = conn
+	return exporter, nil
+}
+
+func newOTLPExporterWithClient(cfg config.Config, client collogspb.LogsServiceClient) *otlpExporter {
+	exporter := &otlpExporter{
+		client: client,
+		resource: &resourcepb.Resource{
+			Attributes: attributeKeyValues(cfg.GetResource().Attributes()),
+		},
+		headers: metadata.New(cfg.OTLPHeaders),
+		timeout: cfg.GetTimeout(),
+		records: make([]*logspb.LogRecord, 0, maxExportBatchSize),
+		flush:   make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go exporter.run(cfg.GetFlushInterval())
+
+	return exporter
+}
+
+func (exporter *otlpExporter) run(interval time.Duration) {
+	defer close(exporter.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-exporter.flush:
+		case <-exporter.stop:
+			return
+		}
+
+		if err := exporter.Flush(context.Background()); err != nil {
+			otel.Handle(err)
+		}
+	}
+}
+
+func (exporter *otlpExporter) enqueue(record *logspb.LogRecord) {
+	exporter.mu.Lock()
+	exporter.records = append(exporter.records, record)
+	full := len(exporter.records) >= maxExportBatchSize
+	exporter.mu.Unlock()
+
+	if full {
+		select {
+		case exporter.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Flush sends every buffered record to the collector, waiting at most cfg.Timeout for it to answer.
+func (exporter *otlpExporter) Flush(ctx context.Context) error {
+	exporter.exportMu.Lock()
+	defer exporter.exportMu.Unlock()
+
+	exporter.mu.Lock()
+	records := exporter.records
+	exporter.records = make([]*logspb.LogRecord, 0, maxExportBatchSize)
+	exporter.mu.Unlock()
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, exporter.timeout)
+	defer cancel()
+	if len(exporter.headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, exporter.headers)
+	}
+
+	_, err := exporter.client.Export(ctx, &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{
+			{
+				Resource: exporter.resource,
+				ScopeLogs: []*logspb.ScopeLogs{
+					{
+						Scope:      &commonpb.InstrumentationScope{Name: instrumentationName},
+						LogRecords: records,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error exporting %d log records: %w", len(records), err)
+	}
+
+	return nil
+}
+
+// Shutdown stops the background flush loop, sends whatever is still buffered and closes the connection. Calling it
+// more than once is a no-op.
+func (exporter *otlpExporter) Shutdown(ctx context.Context) error {
+	var err error
+	exporter.stopOnce.Do(func() {
+		close(exporter.stop)
+		<-exporter.done
+
+		err = exporter.Flush(ctx)
+
+		if exporter.conn != nil {
+			if closeErr := exporter.conn.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}
+	})
+
+	return err
+}
+
+// otlpCore is a zapcore.Core that turns every entry into an OTLP LogRecord and hands it to an otlpExporter.
+type otlpCore struct {
+	zapcore.LevelEnabler
+	exporter *otlpExporter
+	fields   []zapcore.Field
+}
+
+func newOTLPCore(enabler zapcore.LevelEnabler, exporter *otlpExporter) *otlpCore {
+	return &otlpCore{
+		LevelEnabler: enabler,
+		exporter:     exporter,
+	}
+}
+
+func (core *otlpCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *core
+	clone.fields = append(core.fields[:len(core.fields):len(core.fields)], fields...)
+	return &clone
+}
+
+func (core *otlpCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if core.Enabled(entry.Level) {
+		return checked.AddCore(entry, core)
+	}
+	return checked
+}
+
+func (core *otlpCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	core.exporter.enqueue(newLogRecord(entry, append(core.fields[:len(core.fields):len(core.fields)], fields...)))
+
+	// Panic and fatal entries are followed by the end of the process, so they can't wait for the next batch
+	if entry.Level > zapcore.ErrorLevel {
+		return core.Sync()
+	}
+	return nil
+}
+
+func (core *otlpCore) Sync() error {
+	return core.exporter.Flush(context.Background())
+}
+
+func newLogRecord(entry zapcore.Entry, fields []zapcore.Field) *logspb.LogRecord {
+	record := &logspb.LogRecord{}
+	encoder := zapcore.NewMapObjectEncoder()
+	for i := range fields {
+		// The span context belongs to the record itself rather than to its attributes
+		if correlation, ok := fields[i].Interface.(spanContextField); ok {
+			traceID := correlation.spanContext.TraceID()
+			spanID := correlation.spanContext.SpanID()
+			record.TraceId = traceID[:]
+			record.SpanId = spanID[:]
+			record.Flags = uint32(correlation.spanContext.TraceFlags())
+			continue
+		}
+		fields[i].AddTo(encoder)
+	}
+
+	attributes := keyValues(encoder.Fields)
+	if entry.Caller.Defined {
+		attributes = append(attributes,
+			stringKeyValue("code.filepath", entry.Caller.File),
+			&commonpb.KeyValue{Key: "code.lineno", Value: toAnyValue(entry.Caller.Line)},
+			stringKeyValue("code.function", entry.Caller.Function),
+		)
+	}
+	if entry.Stack != "" {
+		attributes = append(attributes, stringKeyValue("stack_trace", entry.Stack))
+	}
+
+	record.TimeUnixNano = uint64(entry.Time.UnixNano())
+	record.ObservedTimeUnixNano = uint64(time.Now().UnixNano())
+	record.SeverityNumber = severityNumber(entry.Level)
+	record.SeverityText = entry.Level.CapitalString()
+	record.Body = toAnyValue(entry.Message)
+	record.Attributes = attributes
+	return record
+}
+
+func severityNumber(level zapcore.Level) logspb.SeverityNumber {
+	switch level {
+	case zapcore.DebugLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG
+	case zapcore.InfoLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_INFO
+	case zapcore.WarnLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_WARN
+	case zapcore.ErrorLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_ERROR
+	case zapcore.DPanicLevel, zapcore.PanicLevel, zapcore.FatalLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_FATAL
+	default:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED
+	}
+}
+
+func stringKeyValue(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key:   key,
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}},
+	}
+}
+
+func attributeKeyValues(attrs []attribute.KeyValue) []*commonpb.KeyValue {
+	keyValues := make([]*commonpb.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		keyValues = append(keyValues, &commonpb.KeyValue{Key: string(attr.Key), Value: toAnyValue(attr.Value.AsInterface())})
+	}
+	return keyValues
+}
+
+// keyValues converts the output of a zapcore.MapObjectEncoder into OTLP attributes, sorted by key so batches are
+// deterministic.
+func keyValues(fields map[string]interface{}) []*commonpb.KeyValue {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attributes := make([]*commonpb.KeyValue, 0, len(fields))
+	for _, key := range keys {
+		attributes = append(attributes, &commonpb.KeyValue{Key: key, Value: toAnyValue(fields[key])})
+	}
+	return attributes
+}
+
+func toAnyValue(value interface{}) *commonpb.AnyValue {
+	switch v := value.(type) {
+	case string:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v}}
+	case int:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case int64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: v}}
+	case int32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case int16:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case int8:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case uint32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case uint16:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case uint8:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case float64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: v}}
+	case float32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: float64(v)}}
+	case time.Time:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v.Format(time.RFC3339Nano)}}
+	case []byte:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: v}}
+	case []interface{}:
+		values := make([]*commonpb.AnyValue, 0, len(v))
+		for _, item := range v {
+			values = append(values, toAnyValue(item))
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}}
+	case map[string]interface{}:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{Values: keyValues(v)}}}
+	default:
+		// uint64, uintptr, durations, complex numbers and reflected values don't map losslessly onto the OTLP types
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: fmt.Sprint(v)}}
+	}
+}
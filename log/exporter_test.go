@@ -0,0 +1,255 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/garden/observability-commons/config"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type fakeLogsClient struct {
+	mu       sync.Mutex
+	requests []*collogspb.ExportLogsServiceRequest
+	headers  []metadata.MD
+}
+
+func (client *fakeLogsClient) Export(ctx context.Context, in *collogspb.ExportLogsServiceRequest, _ ...grpc.CallOption) (*collogspb.ExportLogsServiceResponse, error) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.requests = append(client.requests, in)
+	headers, _ := metadata.FromOutgoingContext(ctx)
+	client.headers = append(client.headers, headers)
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+func (client *fakeLogsClient) records() []*logspb.LogRecord {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	var records []*logspb.LogRecord
+	for _, request := range client.requests {
+		for _, resourceLogs := range request.ResourceLogs {
+			for _, scopeLogs := range resourceLogs.ScopeLogs {
+				records = append(records, scopeLogs.LogRecords...)
+			}
+		}
+	}
+	return records
+}
+
+func testExporterConfig() config.Config {
+	return config.Config{
+		Service: config.Service{
+			Name:    "test-service",
+			Version: "1.0.0",
+		},
+		Mode:          config.Debug,
+		FlushInterval: time.Hour,
+		Timeout:       time.Second,
+	}
+}
+
+func attributeValue(record *logspb.LogRecord, key string) *commonpb.AnyValue {
+	for _, attribute := range record.Attributes {
+		if attribute.Key == key {
+			return attribute.Value
+		}
+	}
+	return nil
+}
+
+func TestOTLPCore_Write(t *testing.T) {
+	tests := []struct {
+		name         string
+		level        zapcore.Level
+		wantSeverity logspb.SeverityNumber
+		wantText     string
+	}{
+		{
+			name:         "debug",
+			level:        zapcore.DebugLevel,
+			wantSeverity: logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG,
+			wantText:     "DEBUG",
+		},
+		{
+			name:         "info",
+			level:        zapcore.InfoLevel,
+			wantSeverity: logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+			wantText:     "INFO",
+		},
+		{
+			name:         "warn",
+			level:        zapcore.WarnLevel,
+			wantSeverity: logspb.SeverityNumber_SEVERITY_NUMBER_WARN,
+			wantText:     "WARN",
+		},
+		{
+			name:         "error",
+			level:        zapcore.ErrorLevel,
+			wantSeverity: logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+			wantText:     "ERROR",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeLogsClient{}
+			exporter := newOTLPExporterWithClient(testExporterConfig(), client)
+			logger := zap.New(newOTLPCore(zapcore.DebugLevel, exporter))
+
+			logger.Check(tt.level, "something happened").Write(
+				zap.String("component", "orders"),
+				zap.Int64("attempt", 3),
+				zap.Error(errors.New("boom")),
+			)
+			assert.Empty(t, client.records(), "records should be batched until the next flush")
+
+			assert.NoError(t, exporter.Shutdown(context.Background()))
+
+			records := client.records()
+			if assert.Len(t, records, 1) {
+				record := records[0]
+				assert.Equal(t, tt.wantSeverity, record.SeverityNumber)
+				assert.Equal(t, tt.wantText, record.SeverityText)
+				assert.Equal(t, "something happened", record.Body.GetStringValue())
+				assert.Equal(t, "orders", attributeValue(record, "component").GetStringValue())
+				assert.Equal(t, int64(3), attributeValue(record, "attempt").GetIntValue())
+				assert.Equal(t, "boom", attributeValue(record, "error").GetStringValue())
+			}
+		})
+	}
+}
+
+func TestOTLPExporter_Resource(t *testing.T) {
+	cfg := testExporterConfig()
+	assert.NoError(t, cfg.Ensure())
+	client := &fakeLogsClient{}
+	exporter := newOTLPExporterWithClient(cfg, client)
+
+	zap.New(newOTLPCore(zapcore.DebugLevel, exporter)).Info("hello")
+	assert.NoError(t, exporter.Shutdown(context.Background()))
+
+	if assert.Len(t, client.requests, 1) {
+		resource := map[string]string{}
+		for _, attribute := range client.requests[0].ResourceLogs[0].Resource.Attributes {
+			resource[attribute.Key] = attribute.Value.GetStringValue()
+		}
+		assert.Equal(t, "test-service", resource["service.name"])
+		assert.Equal(t, "1.0.0", resource["service.version"])
+		assert.Equal(t, instrumentationName, client.requests[0].ResourceLogs[0].ScopeLogs[0].Scope.Name)
+	}
+}
+
+func TestOTLPExporter_Headers(t *testing.T) {
+	cfg := testExporterConfig()
+	cfg.OTLPHeaders = map[string]string{"Authorization": "Bearer token"}
+	client := &fakeLogsClient{}
+	exporter := newOTLPExporterWithClient(cfg, client)
+
+	zap.New(newOTLPCore(zapcore.DebugLevel, exporter)).Info("hello")
+	assert.NoError(t, exporter.Shutdown(context.Background()))
+
+	if assert.Len(t, client.headers, 1) {
+		assert.Equal(t, []string{"Bearer token"}, client.headers[0].Get("authorization"))
+	}
+}
+
+func TestOTLPExporter_FlushesFullBatch(t *testing.T) {
+	client := &fakeLogsClient{}
+	exporter := newOTLPExporterWithClient(testExporterConfig(), client)
+	logger := zap.New(newOTLPCore(zapcore.DebugLevel, exporter))
+
+	for i := 0; i < maxExportBatchSize; i++ {
+		logger.Info("hello")
+	}
+
+	assert.Eventually(t, func() bool {
+		return len(client.records()) == maxExportBatchSize
+	}, time.Second, 10*time.Millisecond)
+	assert.NoError(t, exporter.Shutdown(context.Background()))
+}
+
+func TestOTLPExporter_WithoutEnsure(t *testing.T) {
+	client := &fakeLogsClient{}
+	cfg := testExporterConfig()
+	cfg.FlushInterval = 0
+	cfg.Timeout = 0
+	exporter := newOTLPExporterWithClient(cfg, client)
+
+	zap.New(newOTLPCore(zapcore.DebugLevel, exporter)).Info("hello")
+	assert.NoError(t, exporter.Shutdown(context.Background()))
+
+	assert.Equal(t, config.DefaultTimeout, exporter.timeout)
+	assert.Len(t, client.records(), 1)
+}
+
+func TestNewLogRecord_TraceCorrelation(t *testing.T) {
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	record := newLogRecord(zapcore.Entry{Level: zapcore.InfoLevel, Message: "hello"}, []zapcore.Field{
+		zap.Inline(spanContextField{spanContext: spanContext}),
+		zap.String("component", "orders"),
+	})
+
+	traceID := spanContext.TraceID()
+	spanID := spanContext.SpanID()
+	assert.Equal(t, traceID[:], record.TraceId)
+	assert.Equal(t, spanID[:], record.SpanId)
+	assert.Equal(t, uint32(trace.FlagsSampled), record.Flags)
+	assert.Nil(t, attributeValue(record, traceIDKey))
+	assert.Nil(t, attributeValue(record, spanIDKey))
+	assert.Nil(t, attributeValue(record, traceFlagsKey))
+	assert.Equal(t, "orders", attributeValue(record, "component").GetStringValue())
+}
+
+func TestNewLogRecord_FieldsNamedLikeTheSpanContext(t *testing.T) {
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	})
+	callerTraceID := "0af7651916cd43dd8448eb211c80319c"
+
+	tests := []struct {
+		name      string
+		fields    []zapcore.Field
+		wantTrace []byte
+	}{
+		{
+			name:   "without span context",
+			fields: []zapcore.Field{zap.String(traceIDKey, callerTraceID), zap.String(spanIDKey, "b7ad6b7169203331")},
+		},
+		{
+			name: "with span context",
+			fields: []zapcore.Field{
+				zap.Inline(spanContextField{spanContext: spanContext}),
+				zap.String(traceIDKey, callerTraceID),
+				zap.String(spanIDKey, "b7ad6b7169203331"),
+			},
+			wantTrace: []byte{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := newLogRecord(zapcore.Entry{Level: zapcore.InfoLevel, Message: "hello"}, tt.fields)
+
+			assert.Equal(t, tt.wantTrace, record.TraceId)
+			assert.Equal(t, callerTraceID, attributeValue(record, traceIDKey).GetStringValue())
+			assert.Equal(t, "b7ad6b7169203331", attributeValue(record, spanIDKey).GetStringValue())
+		})
+	}
+}
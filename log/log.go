@@ -6,5 +6,7 @@ type Logger interface {
 	Warn(logEntry *Entry)
 	Error(logEntry *Entry)
 	Fatal(logEntry *Entry)
+	// DroppedEntries returns how many entries were discarded by the LogOverflowPolicy or logged after Close
+	DroppedEntries() uint64
 	Close() error
 }
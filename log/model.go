@@ -1,6 +1,12 @@
 package log
 
+import "context"
+
 type Entry struct {
+	// Ctx, when set, links the entry to the span active in it: exported records carry its trace ID, span ID and flags,
+	// printed entries the trace_id, span_id and trace_flags keys. Fields of the same names are kept as they are
+	Ctx context.Context
+
 	Component string
 	Operation string
 	Message   string
@@ -1,6 +1,7 @@
 package log
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"runtime/debug"
@@ -8,19 +9,27 @@ import (
 
 	"github.com/garden/observability-commons/config"
 	"github.com/garden/observability-commons/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 const (
 	instrumentationName = "github.com/garden/observability-commons"
+
+	traceIDKey    = "trace_id"
+	spanIDKey     = "span_id"
+	traceFlagsKey = "trace_flags"
 )
 
 type OTLPLogger struct {
-	logger *zap.Logger
-	cfg    config.Config
-	tracer trace.Tracer
+	logger   *zap.Logger
+	exporter *otlpExporter
+	queue    *logQueue
+	cfg      config.Config
 }
 
 func NewOTLPLogger(cfg config.Config) (*OTLPLogger, error) {
@@ -45,6 +54,7 @@ func NewOTLPLogger(cfg config.Config) (*OTLPLogger, error) {
 	}
 
 	var core zapcore.Core
+	var exporter *otlpExporter
 	switch cfg.Mode {
 	case config.Noop:
 		core = zapcore.NewCore(
@@ -59,24 +69,30 @@ func NewOTLPLogger(cfg config.Config) (*OTLPLogger, error) {
 			level,
 		)
 	case config.Debug, config.Development, config.Production:
-		core = zapcore.NewCore(
-			zapcore.NewJSONEncoder(encoderConfig),
-			zapcore.AddSync(os.Stdout),
-			level,
-		)
+		var err error
+		exporter, err = newOTLPExporter(cfg)
+		if err != nil {
+			return nil, err
+		}
+		core = newOTLPCore(level, exporter)
 	default:
 		return nil, fmt.Errorf("unknown mode: %v", cfg.Mode)
 	}
 
 	logger := zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
+	if exporter == nil {
+		// Exported entries carry the resource once per batch, printed ones repeat it on every line
+		logger = logger.With(resourceFields(cfg.GetResource())...)
+	}
 
-	tracer := trace.NewNoopTracerProvider().Tracer(instrumentationName)
+	otlpLogger := &OTLPLogger{
+		logger:   logger,
+		exporter: exporter,
+		cfg:      cfg,
+	}
+	otlpLogger.queue = newLogQueue(cfg.LogQueueSize, cfg.LogWorkers, cfg.LogOverflowPolicy, otlpLogger.write)
 
-	return &OTLPLogger{
-		logger: logger,
-		cfg:    cfg,
-		tracer: tracer,
-	}, nil
+	return otlpLogger, nil
 }
 
 func (log *OTLPLogger) Debug(logEntry *Entry) {
@@ -97,51 +113,126 @@ func (log *OTLPLogger) Error(logEntry *Entry) {
 	log.logWithLevel(logEntry, zap.ErrorLevel)
 }
 
+// Fatal writes the entry synchronously, after every entry already queued, and closes the logger so nothing is lost
+// when the process exits. It doesn't exit by itself: ending the process is up to the caller.
 func (log *OTLPLogger) Fatal(logEntry *Entry) {
 	logEntry.stacktrace = string(debug.Stack())
-	log.logWithLevel(logEntry, zap.FatalLevel)
+	fields := log.generateOTLPFields(logEntry)
+
+	ctx, cancel := context.WithTimeout(context.Background(), log.cfg.GetTimeout())
+	defer cancel()
+
+	if err := log.queue.Close(ctx); err != nil {
+		otel.Handle(err)
+	}
+
+	// zap.Logger.Fatal would exit once the entry is written. The entry is checked here, so its caller and stack trace
+	// are the ones of this call, but written from another goroutine, which is all WriteThenGoexit ends.
+	fatalLogger := log.logger.WithOptions(zap.AddCallerSkip(1), zap.OnFatal(zapcore.WriteThenGoexit))
+	if checked := fatalLogger.Check(zap.FatalLevel, logEntry.Message); checked != nil {
+		written := make(chan struct{})
+		go func() {
+			defer close(written)
+			checked.Write(fields...)
+		}()
+		<-written
+	}
+
+	if err := log.Close(); err != nil {
+		otel.Handle(err)
+	}
+}
+
+// DroppedEntries returns how many entries were discarded by the LogOverflowPolicy or logged after Close.
+func (log *OTLPLogger) DroppedEntries() uint64 {
+	return log.queue.Dropped()
 }
 
+// Close writes every queued entry and flushes the exporter, giving up on each after cfg.Timeout. The exporter is shut
+// down even when the queue isn't drained in time, so the entries it already batched are still sent.
 func (log *OTLPLogger) Close() error {
-	return log.logger.Sync()
+	ctx, cancel := context.WithTimeout(context.Background(), log.cfg.GetTimeout())
+	defer cancel()
+
+	queueErr := log.queue.Close(ctx)
+	syncErr := log.logger.Sync()
+
+	var shutdownErr error
+	if log.exporter != nil {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), log.cfg.GetTimeout())
+		defer shutdownCancel()
+		shutdownErr = log.exporter.Shutdown(shutdownCtx)
+	}
+
+	return multierr.Combine(queueErr, syncErr, shutdownErr)
 }
 
 func (log *OTLPLogger) logWithLevel(logEntry *Entry, level zapcore.Level) {
-	fields := log.generateOTLPFields(logEntry)
+	log.queue.push(queuedEntry{
+		level:   level,
+		message: logEntry.Message,
+		fields:  log.generateOTLPFields(logEntry),
+	})
+}
 
-	go func() {
-		switch level {
-		case zap.DebugLevel:
-			log.logger.Debug(logEntry.Message, fields...)
-		case zap.InfoLevel:
-			log.logger.Info(logEntry.Message, fields...)
-		case zap.WarnLevel:
-			log.logger.Warn(logEntry.Message, fields...)
-		case zap.ErrorLevel:
-			log.logger.Error(logEntry.Message, fields...)
-		case zap.FatalLevel:
-			log.logger.Fatal(logEntry.Message, fields...)
-		}
-	}()
+func (log *OTLPLogger) write(entry queuedEntry) {
+	switch entry.level {
+	case zap.DebugLevel:
+		log.logger.Debug(entry.message, entry.fields...)
+	case zap.InfoLevel:
+		log.logger.Info(entry.message, entry.fields...)
+	case zap.WarnLevel:
+		log.logger.Warn(entry.message, entry.fields...)
+	case zap.ErrorLevel:
+		log.logger.Error(entry.message, entry.fields...)
+	}
+}
+
+// spanContextField links an entry to a span. It stays apart from the caller's fields, so a field named trace_id,
+// span_id or trace_flags neither replaces nor is taken for the span context. Printed entries show it inline.
+type spanContextField struct {
+	spanContext trace.SpanContext
+}
+
+func (field spanContextField) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddString(traceIDKey, field.spanContext.TraceID().String())
+	encoder.AddString(spanIDKey, field.spanContext.SpanID().String())
+	encoder.AddString(traceFlagsKey, field.spanContext.TraceFlags().String())
+	return nil
+}
+
+func resourceFields(res *resource.Resource) []zap.Field {
+	attrs := res.Attributes()
+	fields := make([]zap.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = append(fields, zap.Any(string(attr.Key), attr.Value.AsInterface()))
+	}
+	return fields
 }
 
 func (log *OTLPLogger) generateOTLPFields(logEntry *Entry) []zap.Field {
 	fields := []zap.Field{
-		zap.String("service.name", log.cfg.Service.Name),
-		zap.String("service.version", log.cfg.Service.Version),
-		zap.String("host.name", log.cfg.GetHostname()),
 		zap.String("component", logEntry.Component),
 		zap.String("operation", logEntry.Operation),
 		zap.Time("timestamp", time.Now()),
 	}
 
+	if logEntry.Ctx != nil {
+		if spanContext := trace.SpanContextFromContext(logEntry.Ctx); spanContext.IsValid() {
+			fields = append(fields, zap.Inline(spanContextField{spanContext: spanContext}))
+		}
+	}
+
 	if logEntry.Err != nil {
 		fields = append(fields, zap.Error(logEntry.Err))
+		fields = append(fields, zap.String("error.type", util.GetErrorName(util.GetRootCause(logEntry.Err))))
+		fields = append(fields, zap.Array("error.chain", util.GetErrorChain(logEntry.Err)))
 	}
 
 	if logEntry.stacktrace != "" {
-		stacktraceHash := util.MD5Hash([]byte(logEntry.stacktrace))
-		fields = append(fields, zap.String("stacktrace.hash", stacktraceHash))
+		frames := util.ParseStacktrace([]byte(logEntry.stacktrace))
+		fields = append(fields, zap.String("stacktrace.hash", frames.Fingerprint(false)))
+		fields = append(fields, zap.Array("stacktrace.frames", frames))
 		fields = append(fields, zap.String("stacktrace", logEntry.stacktrace))
 	}
 
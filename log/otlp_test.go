@@ -0,0 +1,150 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/garden/observability-commons/config"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func fieldMap(fields []zapcore.Field) map[string]interface{} {
+	encoder := zapcore.NewMapObjectEncoder()
+	for i := range fields {
+		fields[i].AddTo(encoder)
+	}
+	return encoder.Fields
+}
+
+func TestOTLPLogger_generateOTLPFields_TraceCorrelation(t *testing.T) {
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want map[string]interface{}
+	}{
+		{
+			name: "no context",
+			ctx:  nil,
+		},
+		{
+			name: "context without span",
+			ctx:  context.Background(),
+		},
+		{
+			name: "context with span",
+			ctx:  trace.ContextWithSpanContext(context.Background(), spanContext),
+			want: map[string]interface{}{
+				traceIDKey:    "4bf92f3577b34da6a3ce929d0e0e4736",
+				spanIDKey:     "00f067aa0ba902b7",
+				traceFlagsKey: "01",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &OTLPLogger{cfg: config.Config{}}
+
+			fields := fieldMap(logger.generateOTLPFields(&Entry{Ctx: tt.ctx, Message: "hello"}))
+
+			for _, key := range []string{traceIDKey, spanIDKey, traceFlagsKey} {
+				if tt.want == nil {
+					assert.NotContains(t, fields, key)
+				} else {
+					assert.Equal(t, tt.want[key], fields[key])
+				}
+			}
+		})
+	}
+}
+
+func TestOTLPLogger_generateOTLPFields_ErrorChain(t *testing.T) {
+	logger := &OTLPLogger{cfg: config.Config{}}
+	err := fmt.Errorf("error reading orders: %w", context.Canceled)
+
+	fields := fieldMap(logger.generateOTLPFields(&Entry{Message: "hello", Err: err}))
+
+	assert.Equal(t, "error reading orders: context canceled", fields["error"])
+	assert.Equal(t, "errors.errorString", fields["error.type"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"type": "fmt.wrapError", "message": "error reading orders: context canceled"},
+		map[string]interface{}{"type": "errors.errorString", "message": "context canceled"},
+	}, fields["error.chain"])
+}
+
+func TestOTLPLogger_CloseDeadline(t *testing.T) {
+	cfg := testExporterConfig()
+	cfg.Timeout = 10 * time.Millisecond
+
+	client := &fakeLogsClient{}
+	exporter := newOTLPExporterWithClient(cfg, client)
+	writer := newRecordingWriter(true)
+	logger := &OTLPLogger{
+		logger:   zap.New(newOTLPCore(zapcore.DebugLevel, exporter)),
+		exporter: exporter,
+		queue:    newLogQueue(1, 1, config.Block, writer.write),
+		cfg:      cfg,
+	}
+	defer close(writer.release)
+
+	logger.logger.Info("batched")
+	logger.queue.push(queuedEntry{message: "stuck"})
+	<-writer.started
+
+	// The queue can't drain in time, but the exporter still sends its batch and stops
+	assert.ErrorIs(t, logger.Close(), context.DeadlineExceeded)
+	if records := client.records(); assert.Len(t, records, 1) {
+		assert.Equal(t, "batched", records[0].Body.GetStringValue())
+	}
+	select {
+	case <-exporter.done:
+	default:
+		t.Fatal("the exporter is still running")
+	}
+}
+
+func TestOTLPLogger_FatalIsSynchronous(t *testing.T) {
+	cfg := testExporterConfig()
+	cfg.LogQueueSize = 16
+	cfg.LogWorkers = 1
+
+	client := &fakeLogsClient{}
+	exporter := newOTLPExporterWithClient(cfg, client)
+	logger := &OTLPLogger{
+		logger:   zap.New(newOTLPCore(zapcore.DebugLevel, exporter), zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel)),
+		exporter: exporter,
+		cfg:      cfg,
+	}
+	logger.queue = newLogQueue(cfg.LogQueueSize, cfg.LogWorkers, cfg.LogOverflowPolicy, logger.write)
+
+	for i := 0; i < 3; i++ {
+		logger.Info(&Entry{Message: fmt.Sprintf("entry-%d", i)})
+	}
+	logger.Fatal(&Entry{Message: "fatal", Err: errors.New("boom")})
+
+	// Everything must already be exported when Fatal returns
+	records := client.records()
+	if assert.Len(t, records, 4) {
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, fmt.Sprintf("entry-%d", i), records[i].Body.GetStringValue())
+		}
+		assert.Equal(t, "fatal", records[3].Body.GetStringValue())
+		assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_FATAL, records[3].SeverityNumber)
+		assert.NotNil(t, attributeValue(records[3], "stacktrace"))
+		// The caller is whoever called Fatal, and zap's stack trace starts there too
+		assert.Contains(t, attributeValue(records[3], "code.function").GetStringValue(), "log.TestOTLPLogger_FatalIsSynchronous")
+		assert.Contains(t, attributeValue(records[3], "stack_trace").GetStringValue(), "log.TestOTLPLogger_FatalIsSynchronous")
+	}
+}
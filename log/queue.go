@@ -0,0 +1,153 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/garden/observability-commons/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type queuedEntry struct {
+	level   zapcore.Level
+	message string
+	fields  []zap.Field
+}
+
+// logQueue hands entries over to a fixed pool of workers through a bounded buffer, applying policy when it is full.
+type logQueue struct {
+	entries chan queuedEntry
+	policy  config.OverflowPolicy
+	write   func(queuedEntry)
+
+	// mu guards closed so no push starts once Close has begun. Pushes already started are tracked by pushing, and
+	// entries is closed once they are all over, so none of them sends to it after that.
+	mu      sync.RWMutex
+	closed  bool
+	closing chan struct{}
+	pushing sync.WaitGroup
+
+	workers sync.WaitGroup
+	dropped uint64
+}
+
+// newLogQueue starts at least one worker with room for at least one entry, since configs that weren't ensured leave
+// both at 0 and nothing would drain the queue.
+func newLogQueue(size, workers int, policy config.OverflowPolicy, write func(queuedEntry)) *logQueue {
+	if size < 1 {
+		size = 1
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	queue := &logQueue{
+		entries: make(chan queuedEntry, size),
+		policy:  policy,
+		write:   write,
+		closing: make(chan struct{}),
+	}
+
+	queue.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go queue.run()
+	}
+
+	return queue
+}
+
+func (queue *logQueue) run() {
+	defer queue.workers.Done()
+
+	for entry := range queue.entries {
+		queue.write(entry)
+	}
+}
+
+func (queue *logQueue) push(entry queuedEntry) {
+	if !queue.begin() {
+		atomic.AddUint64(&queue.dropped, 1)
+		return
+	}
+	defer queue.pushing.Done()
+
+	switch queue.policy {
+	case config.DropNewest:
+		select {
+		case queue.entries <- entry:
+		default:
+			atomic.AddUint64(&queue.dropped, 1)
+		}
+	case config.DropOldest:
+		for {
+			select {
+			case queue.entries <- entry:
+				return
+			default:
+			}
+
+			select {
+			case <-queue.entries:
+				atomic.AddUint64(&queue.dropped, 1)
+			default:
+			}
+		}
+	default:
+		// Waits for a worker to make room, unless the queue is closed meanwhile
+		select {
+		case queue.entries <- entry:
+		case <-queue.closing:
+			atomic.AddUint64(&queue.dropped, 1)
+		}
+	}
+}
+
+// begin registers a push, unless the queue is closed. The lock is only held while registering, so Close doesn't wait
+// for a push blocked on a full queue.
+func (queue *logQueue) begin() bool {
+	queue.mu.RLock()
+	defer queue.mu.RUnlock()
+
+	if queue.closed {
+		return false
+	}
+	queue.pushing.Add(1)
+	return true
+}
+
+// Dropped returns how many entries were discarded because the queue was full or already closed.
+func (queue *logQueue) Dropped() uint64 {
+	return atomic.LoadUint64(&queue.dropped)
+}
+
+// Close stops accepting entries and waits for the workers to write everything already queued, giving up when ctx is
+// done. Pushes blocked on a full queue give up and count their entry as dropped.
+func (queue *logQueue) Close(ctx context.Context) error {
+	queue.mu.Lock()
+	first := !queue.closed
+	if first {
+		queue.closed = true
+		close(queue.closing)
+	}
+	queue.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		if first {
+			queue.pushing.Wait()
+			close(queue.entries)
+		}
+		queue.workers.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("error draining log queue: %d entries left: %w", len(queue.entries), ctx.Err())
+	}
+}
@@ -0,0 +1,186 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/garden/observability-commons/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingWriter struct {
+	mu       sync.Mutex
+	messages []string
+
+	started chan struct{}
+	release chan struct{}
+}
+
+func newRecordingWriter(gated bool) *recordingWriter {
+	writer := &recordingWriter{}
+	if gated {
+		writer.started = make(chan struct{}, 1)
+		writer.release = make(chan struct{})
+	}
+	return writer
+}
+
+func (writer *recordingWriter) write(entry queuedEntry) {
+	if writer.release != nil {
+		select {
+		case writer.started <- struct{}{}:
+		default:
+		}
+		<-writer.release
+	}
+
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	writer.messages = append(writer.messages, entry.message)
+}
+
+func (writer *recordingWriter) written() []string {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	return append([]string(nil), writer.messages...)
+}
+
+func TestLogQueue_KeepsOrder(t *testing.T) {
+	writer := newRecordingWriter(false)
+	queue := newLogQueue(16, 1, config.Block, writer.write)
+
+	want := make([]string, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		message := fmt.Sprintf("entry-%d", i)
+		want = append(want, message)
+		queue.push(queuedEntry{message: message})
+	}
+
+	assert.NoError(t, queue.Close(context.Background()))
+	assert.Equal(t, want, writer.written())
+	assert.Zero(t, queue.Dropped())
+}
+
+func TestLogQueue_OverflowPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      config.OverflowPolicy
+		wantWritten []string
+	}{
+		{
+			name:        "drop newest",
+			policy:      config.DropNewest,
+			wantWritten: []string{"entry-0", "entry-1", "entry-2"},
+		},
+		{
+			name:        "drop oldest",
+			policy:      config.DropOldest,
+			wantWritten: []string{"entry-0", "entry-4", "entry-5"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := newRecordingWriter(true)
+			queue := newLogQueue(2, 1, tt.policy, writer.write)
+
+			// The worker holds entry-0 while the queue fills up with the following ones
+			queue.push(queuedEntry{message: "entry-0"})
+			<-writer.started
+			for i := 1; i < 6; i++ {
+				queue.push(queuedEntry{message: fmt.Sprintf("entry-%d", i)})
+			}
+
+			close(writer.release)
+			assert.NoError(t, queue.Close(context.Background()))
+			assert.Equal(t, tt.wantWritten, writer.written())
+			assert.Equal(t, uint64(3), queue.Dropped())
+		})
+	}
+}
+
+func TestLogQueue_CloseDeadline(t *testing.T) {
+	writer := newRecordingWriter(true)
+	queue := newLogQueue(4, 1, config.Block, writer.write)
+
+	queue.push(queuedEntry{message: "entry-0"})
+	queue.push(queuedEntry{message: "entry-1"})
+	<-writer.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, queue.Close(ctx), context.DeadlineExceeded)
+
+	close(writer.release)
+	assert.NoError(t, queue.Close(context.Background()))
+	assert.Equal(t, []string{"entry-0", "entry-1"}, writer.written())
+}
+
+func TestLogQueue_CloseWithBlockedPush(t *testing.T) {
+	writer := newRecordingWriter(true)
+	queue := newLogQueue(1, 1, config.Block, writer.write)
+
+	// The worker holds entry-0 and entry-1 fills the queue, so entry-2 blocks
+	queue.push(queuedEntry{message: "entry-0"})
+	<-writer.started
+	queue.push(queuedEntry{message: "entry-1"})
+	pushed := make(chan struct{})
+	go func() {
+		queue.push(queuedEntry{message: "entry-2"})
+		close(pushed)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	closed := make(chan error, 1)
+	go func() {
+		closed <- queue.Close(ctx)
+	}()
+
+	select {
+	case err := <-closed:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("Close waited for the blocked push past its deadline")
+	}
+	<-pushed
+
+	close(writer.release)
+	assert.NoError(t, queue.Close(context.Background()))
+	assert.Equal(t, []string{"entry-0", "entry-1"}, writer.written())
+	assert.Equal(t, uint64(1), queue.Dropped())
+}
+
+func TestLogQueue_WithoutEnsure(t *testing.T) {
+	writer := newRecordingWriter(false)
+	queue := newLogQueue(0, 0, config.Block, writer.write)
+
+	pushed := make(chan struct{})
+	go func() {
+		queue.push(queuedEntry{message: "entry-0"})
+		queue.push(queuedEntry{message: "entry-1"})
+		close(pushed)
+	}()
+
+	select {
+	case <-pushed:
+	case <-time.After(time.Second):
+		t.Fatal("nothing drains the queue")
+	}
+	assert.NoError(t, queue.Close(context.Background()))
+	assert.Equal(t, []string{"entry-0", "entry-1"}, writer.written())
+}
+
+func TestLogQueue_PushAfterClose(t *testing.T) {
+	writer := newRecordingWriter(false)
+	queue := newLogQueue(4, 1, config.Block, writer.write)
+
+	assert.NoError(t, queue.Close(context.Background()))
+	assert.NotPanics(t, func() {
+		queue.push(queuedEntry{message: "late"})
+	})
+	assert.Empty(t, writer.written())
+	assert.Equal(t, uint64(1), queue.Dropped())
+}
@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/garden/observability-commons/util"
+)
+
+const (
+	buildInfoMetric = "build_info"
+)
+
+// publishBuildInfo publishes the build_info gauge, always 1, with the build attributes next to the default ones, so
+// dashboards can spot instances running another version.
+func (meter OtelMeter) publishBuildInfo(build util.BuildInfo) error {
+	instruments := newAsyncInstruments(meter.meter, meter.views, meter.defaultAttrs)
+	gauge, err := newCollectedInstrument(instruments, buildInfoMetric, "", int64Gauge)
+	if err != nil {
+		return fmt.Errorf("error creating %s gauge: %w", buildInfoMetric, err)
+	}
+
+	attrs := build.Attributes()
+	if err = instruments.register(func(ctx context.Context) { gauge.observe(ctx, 1, attrs...) }); err != nil {
+		return fmt.Errorf("error creating %s gauge: %w", buildInfoMetric, err)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/garden/observability-commons/config"
+	"github.com/garden/observability-commons/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOtelMeter_publishBuildInfo(t *testing.T) {
+	collecting := newFakeMeter()
+	meter := newTestMeter(collecting, config.Config{Service: config.Service{Name: "checkout", Version: "1.0.0"}})
+
+	assert.NoError(t, meter.publishBuildInfo(util.BuildInfo{GoVersion: "go1.18.3", ModuleVersion: "v1.4.0", Revision: "4ca4ac6e1f"}))
+
+	for i := 0; i < 2; i++ {
+		observed := collecting.collect()[buildInfoMetric]
+		if assert.Len(t, observed, 1) {
+			assert.Equal(t, float64(1), observed[0].value)
+			assert.Equal(t, "checkout", observed[0].attrs["garden.app.name"])
+			assert.Equal(t, "v1.4.0", observed[0].attrs["garden.build.version"])
+			assert.Equal(t, "4ca4ac6e1f", observed[0].attrs["garden.build.revision"])
+			assert.Equal(t, "false", observed[0].attrs["garden.build.modified"])
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package metrics
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	overflowAttribute = attribute.Key("otel.metric.overflow")
+)
+
+// keyFilter applies the allow and deny lists to the keys of the fields passed to metrics.
+type keyFilter struct {
+	allow map[string]struct{}
+	deny  map[string]struct{}
+}
+
+func newKeyFilter(allow, deny []string) keyFilter {
+	return keyFilter{allow: toKeySet(allow), deny: toKeySet(deny)}
+}
+
+func toKeySet(keys []string) map[string]struct{} {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	set := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		set[key] = struct{}{}
+	}
+	return set
+}
+
+func (filter keyFilter) allows(key string) bool {
+	if _, denied := filter.deny[key]; denied {
+		return false
+	}
+	if filter.allow == nil {
+		return true
+	}
+	_, allowed := filter.allow[key]
+	return allowed
+}
+
+// cardinalityLimiter bounds the distinct attribute sets recorded by each metric. Once a metric reaches the limit,
+// measurements with a new set are rejected, to be recorded into the metric's overflow series instead.
+type cardinalityLimiter struct {
+	limit int
+	warn  func(err error)
+
+	mu      sync.RWMutex
+	metrics map[string]*metricSets
+	warned  bool
+
+	rejected uint64
+}
+
+// metricSets are keyed by appendSetKey. A rejected set is counted once however often it is measured.
+type metricSets struct {
+	sets     map[string]struct{}
+	rejected map[string]struct{}
+}
+
+func newCardinalityLimiter(limit int) *cardinalityLimiter {
+	return &cardinalityLimiter{
+		limit:   limit,
+		warn:    otel.Handle,
+		metrics: map[string]*metricSets{},
+	}
+}
+
+// admit reports whether the metric may record attrs, either because it already has their set or because it has room
+// for a new one. It warns the first time it rejects a set, whatever the metric.
+func (limiter *cardinalityLimiter) admit(metricName string, attrs []attribute.KeyValue) bool {
+	if limiter.limit <= 0 {
+		return true
+	}
+
+	var buffer [256]byte
+	key := appendSetKey(buffer[:0], attrs)
+
+	// Looking a []byte converted to string up doesn't allocate, so neither do measurements of known sets, admitted or
+	// rejected
+	limiter.mu.RLock()
+	if metric, ok := limiter.metrics[metricName]; ok {
+		if _, ok := metric.sets[string(key)]; ok {
+			limiter.mu.RUnlock()
+			return true
+		}
+		if _, ok := metric.rejected[string(key)]; ok && len(metric.sets) >= limiter.limit {
+			limiter.mu.RUnlock()
+			return false
+		}
+	}
+	limiter.mu.RUnlock()
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	metric, ok := limiter.metrics[metricName]
+	if !ok {
+		metric = &metricSets{sets: map[string]struct{}{}, rejected: map[string]struct{}{}}
+		limiter.metrics[metricName] = metric
+	}
+	if _, ok := metric.sets[string(key)]; ok {
+		return true
+	}
+	if len(metric.sets) < limiter.limit {
+		metric.sets[string(key)] = struct{}{}
+		return true
+	}
+
+	if _, ok := metric.rejected[string(key)]; !ok {
+		metric.rejected[string(key)] = struct{}{}
+		atomic.AddUint64(&limiter.rejected, 1)
+	}
+	if !limiter.warned {
+		limiter.warned = true
+		limiter.warn(fmt.Errorf("metric %s reached its limit of %d attribute sets, new ones are recorded with %s=true",
+			metricName, limiter.limit, overflowAttribute))
+	}
+	return false
+}
+
+// release frees the slot of the attribute set, e.g. once its gauge series is removed.
+func (limiter *cardinalityLimiter) release(metricName string, attrs []attribute.KeyValue) {
+	if limiter.limit <= 0 {
+		return
+	}
+
+	var buffer [256]byte
+	key := appendSetKey(buffer[:0], attrs)
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	if metric, ok := limiter.metrics[metricName]; ok {
+		delete(metric.sets, string(key))
+	}
+}
+
+// rejectedSets returns how many distinct attribute sets were rejected, summed over the metrics.
+func (limiter *cardinalityLimiter) rejectedSets() uint64 {
+	return atomic.LoadUint64(&limiter.rejected)
+}
+
+// appendSetKey appends to buffer a key identifying the attribute set of attrs, like attribute.NewSet but without its
+// allocations: attrs are sorted by key, since fields come from a map, and the last value of a duplicate key wins.
+func appendSetKey(buffer []byte, attrs []attribute.KeyValue) []byte {
+	var sortBuffer [16]attribute.KeyValue
+	sorted := append(sortBuffer[:0], attrs...)
+	// A stable insertion sort, sets being small, keeps duplicate keys in their order
+	for i := 1; i < len(sorted); i++ {
+		for j := i; j > 0 && sorted[j].Key < sorted[j-1].Key; j-- {
+			sorted[j], sorted[j-1] = sorted[j-1], sorted[j]
+		}
+	}
+
+	for i, attr := range sorted {
+		if i+1 < len(sorted) && sorted[i+1].Key == attr.Key {
+			continue
+		}
+		// Length prefixes and the value type keep the keys of different sets apart
+		buffer = appendKeyPart(buffer, string(attr.Key))
+		buffer = append(buffer, byte(attr.Value.Type()))
+		buffer = appendKeyPart(buffer, attr.Value.Emit())
+	}
+	return buffer
+}
+
+func appendKeyPart(buffer []byte, part string) []byte {
+	buffer = strconv.AppendInt(buffer, int64(len(part)), 10)
+	buffer = append(buffer, ':')
+	return append(buffer, part...)
+}
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/garden/observability-commons/config"
+	"github.com/garden/observability-commons/util"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestKeyFilter_allows(t *testing.T) {
+	tests := []struct {
+		name  string
+		allow []string
+		deny  []string
+		key   string
+		want  bool
+	}{
+		{name: "no lists", key: "order_id", want: true},
+		{name: "denied", deny: []string{"order_id"}, key: "order_id", want: false},
+		{name: "allowed", allow: []string{"route"}, key: "route", want: true},
+		{name: "not in the allow list", allow: []string{"route"}, key: "order_id", want: false},
+		{name: "deny wins over allow", allow: []string{"route"}, deny: []string{"route"}, key: "route", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, newKeyFilter(tt.allow, tt.deny).allows(tt.key))
+		})
+	}
+}
+
+func TestCardinalityLimiter_admit(t *testing.T) {
+	limiter := newCardinalityLimiter(2)
+	var warnings []error
+	limiter.warn = func(err error) {
+		warnings = append(warnings, err)
+	}
+
+	first := []attribute.KeyValue{attribute.String("order_id", "1")}
+	second := []attribute.KeyValue{attribute.String("order_id", "2")}
+	third := []attribute.KeyValue{attribute.String("order_id", "3")}
+
+	assert.True(t, limiter.admit("orders.created", first))
+	assert.True(t, limiter.admit("orders.created", second))
+	assert.True(t, limiter.admit("orders.created", first), "known sets are always admitted")
+	assert.False(t, limiter.admit("orders.created", third))
+	assert.False(t, limiter.admit("orders.created", third), "rejected sets stay rejected")
+	assert.False(t, limiter.admit("orders.created", []attribute.KeyValue{attribute.String("order_id", "4")}))
+	assert.True(t, limiter.admit("orders.cancelled", third), "each metric has its own limit")
+	assert.True(t, limiter.admit("orders.cancelled", first))
+	assert.False(t, limiter.admit("orders.cancelled", second))
+
+	assert.Equal(t, uint64(3), limiter.rejectedSets(), "each rejected set is counted once per metric")
+	assert.Len(t, warnings, 1, "the warning is logged once")
+
+	limiter.release("orders.created", first)
+	assert.True(t, limiter.admit("orders.created", third), "released sets free their slot")
+}
+
+func TestAppendSetKey(t *testing.T) {
+	key := func(attrs ...attribute.KeyValue) string {
+		return string(appendSetKey(nil, attrs))
+	}
+	route, method := attribute.String("route", "/orders"), attribute.String("method", "GET")
+
+	assert.Equal(t, key(route, method), key(method, route), "order doesn't matter")
+	assert.Equal(t, key(attribute.String("route", "/"), method, route), key(method, route), "the last duplicate wins")
+	assert.NotEqual(t, key(attribute.String("a", "b:1:c")), key(attribute.String("a", "b"), attribute.String("c", "")))
+	assert.NotEqual(t, key(attribute.String("code", "1")), key(attribute.Int("code", 1)))
+}
+
+func TestOtelMeter_CardinalityLimit(t *testing.T) {
+	recording := newFakeMeter()
+	meter := newTestMeter(recording, config.Config{
+		Service:                 config.Service{Name: "checkout", Version: "1.0.0"},
+		MetricCardinalityLimit:  1,
+		MetricAttributeDenyList: []string{"user_id"},
+	})
+	meter.limiter.warn = func(error) {}
+	ctx := context.Background()
+
+	assert.NoError(t, meter.DefaultCounter(ctx, "orders.created", 1, util.ExtraFields{"order_id": "1", "user_id": "42"}))
+	assert.NoError(t, meter.DefaultCounter(ctx, "orders.created", 1, util.ExtraFields{"order_id": "2", "user_id": "42"}))
+	assert.NoError(t, meter.DefaultCounter(ctx, "orders.created", 1, util.ExtraFields{"order_id": "2", "user_id": "43"}))
+
+	if assert.Len(t, recording.measurements, 3) {
+		first := attrsByKey(recording.measurements[0].attrs)
+		assert.Equal(t, "1", first["order_id"])
+		assert.NotContains(t, first, attribute.Key("user_id"))
+
+		overflow := attrsByKey(recording.measurements[1].attrs)
+		assert.Equal(t, "true", overflow[overflowAttribute])
+		assert.Equal(t, "checkout", overflow["garden.app.name"], "default attributes are kept on the overflow series")
+		assert.NotContains(t, overflow, attribute.Key("order_id"))
+	}
+	assert.Equal(t, uint64(1), meter.RejectedAttributeSets(), "user_id is dropped before the set is limited")
+}
+
+func attrsByKey(attrs []attribute.KeyValue) map[attribute.Key]string {
+	byKey := map[attribute.Key]string{}
+	for _, attr := range attrs {
+		byKey[attr.Key] = attr.Value.Emit()
+	}
+	return byKey
+}
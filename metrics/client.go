@@ -1,36 +1,201 @@
 package metrics
 
 import (
+	"context"
 	"fmt"
+	"os"
 
 	"github.com/garden/observability-commons/config"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
-)
-
-const (
-	prodEndpoint  = "otel-collector.garden.internal"
-	devEndpoint   = "localhost:4317"
-	debugEndpoint = "localhost:4317"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric/export"
+	"go.opentelemetry.io/otel/sdk/metric/export/aggregation"
+	"go.opentelemetry.io/otel/sdk/metric/sdkapi"
+	"go.opentelemetry.io/otel/sdk/resource"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 func newClient(cfg config.Config) otlpmetric.Client {
 	return otlpmetricgrpc.NewClient(
 		otlpmetricgrpc.WithInsecure(),
-		otlpmetricgrpc.WithEndpoint(endpoint(cfg.Mode, cfg.Port)),
+		otlpmetricgrpc.WithEndpoint(cfg.GetEndpoint()),
 		otlpmetricgrpc.WithTimeout(cfg.Timeout),
+		otlpmetricgrpc.WithHeaders(cfg.OTLPHeaders),
 	)
 }
 
-func endpoint(mode config.Mode, port string) string {
-	switch mode {
-	case config.Debug:
-		return debugEndpoint
-	case config.Development:
-		return devEndpoint
-	case config.Production:
-		return fmt.Sprintf("%s:%s", prodEndpoint, port)
+// exponentialExporter hands every record to the wrapped exporter except exponential histograms, which the SDK's
+// exporters don't support. It encodes those itself and sends them with upload, e.g. the UploadMetrics method of the
+// OTLP client the wrapped exporter uses. Records are read with the configured temporality whatever the wrapped
+// exporter asks for, as the stdout exporter can't be configured.
+type exponentialExporter struct {
+	export.Exporter
+	temporality aggregation.TemporalitySelector
+	upload      func(ctx context.Context, resourceMetrics *metricpb.ResourceMetrics) error
+}
+
+func newExponentialExporter(exporter export.Exporter, temporality aggregation.TemporalitySelector, upload func(ctx context.Context, resourceMetrics *metricpb.ResourceMetrics) error) exponentialExporter {
+	return exponentialExporter{Exporter: exporter, temporality: temporality, upload: upload}
+}
+
+func (exporter exponentialExporter) TemporalityFor(descriptor *sdkapi.Descriptor, kind aggregation.Kind) aggregation.Temporality {
+	return exporter.temporality.TemporalityFor(descriptor, kind)
+}
+
+func (exporter exponentialExporter) Export(ctx context.Context, res *resource.Resource, reader export.InstrumentationLibraryReader) error {
+	if err := exporter.Exporter.Export(ctx, res, exponentialFilter{reader, exporter.temporality}); err != nil {
+		return err
+	}
+
+	resourceMetrics, err := encodeExponentialHistograms(res, reader, exporter.temporality)
+	if err != nil {
+		return fmt.Errorf("error encoding exponential histograms: %w", err)
+	}
+	if resourceMetrics == nil {
+		return nil
+	}
+	return exporter.upload(ctx, resourceMetrics)
+}
+
+// printResourceMetrics is the upload function of local mode, writing the metrics to stdout like the stdout exporter.
+func printResourceMetrics(_ context.Context, resourceMetrics *metricpb.ResourceMetrics) error {
+	encoded, err := protojson.MarshalOptions{Multiline: true}.Marshal(resourceMetrics)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(os.Stdout, string(encoded))
+	return err
+}
+
+// exponentialFilter hides exponential histograms from the libraries' readers.
+type exponentialFilter struct {
+	export.InstrumentationLibraryReader
+	temporality aggregation.TemporalitySelector
+}
+
+func (filter exponentialFilter) ForEach(readerFunc func(instrumentation.Library, export.Reader) error) error {
+	return filter.InstrumentationLibraryReader.ForEach(func(library instrumentation.Library, reader export.Reader) error {
+		return readerFunc(library, exponentialFilterReader{reader, filter.temporality})
+	})
+}
+
+type exponentialFilterReader struct {
+	export.Reader
+	temporality aggregation.TemporalitySelector
+}
+
+func (reader exponentialFilterReader) ForEach(_ aggregation.TemporalitySelector, recordFunc func(export.Record) error) error {
+	return reader.Reader.ForEach(reader.temporality, func(record export.Record) error {
+		if _, ok := record.Aggregation().(*exponentialAggregator); ok {
+			return nil
+		}
+		return recordFunc(record)
+	})
+}
+
+// encodeExponentialHistograms encodes the exponential histograms of every library as OTLP, returning nil when there
+// are none.
+func encodeExponentialHistograms(res *resource.Resource, reader export.InstrumentationLibraryReader, selector aggregation.TemporalitySelector) (*metricpb.ResourceMetrics, error) {
+	var scopeMetrics []*metricpb.ScopeMetrics
+	err := reader.ForEach(func(library instrumentation.Library, libraryReader export.Reader) error {
+		var metrics []*metricpb.Metric
+		err := libraryReader.ForEach(selector, func(record export.Record) error {
+			histogram, ok := record.Aggregation().(*exponentialAggregator)
+			if !ok {
+				return nil
+			}
+			metrics = append(metrics, encodeExponentialHistogram(record, histogram.snapshot(), selector))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(metrics) > 0 {
+			scopeMetrics = append(scopeMetrics, &metricpb.ScopeMetrics{
+				Scope:     &commonpb.InstrumentationScope{Name: library.Name, Version: library.Version},
+				Metrics:   metrics,
+				SchemaUrl: library.SchemaURL,
+			})
+		}
+		return nil
+	})
+	if err != nil || len(scopeMetrics) == 0 {
+		return nil, err
+	}
+
+	resourceMetrics := &metricpb.ResourceMetrics{ScopeMetrics: scopeMetrics}
+	if res != nil {
+		iterator := res.Iter()
+		resourceMetrics.Resource = &resourcepb.Resource{Attributes: encodeAttributes(&iterator)}
+		resourceMetrics.SchemaUrl = res.SchemaURL()
+	}
+	return resourceMetrics, nil
+}
+
+func encodeExponentialHistogram(record export.Record, state exponentialState, selector aggregation.TemporalitySelector) *metricpb.Metric {
+	descriptor := record.Descriptor()
+	temporality := metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+	if selector.TemporalityFor(descriptor, exponentialHistogramKind) == aggregation.DeltaTemporality {
+		temporality = metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+	}
+
+	iterator := record.Attributes().Iter()
+	return &metricpb.Metric{
+		Name:        descriptor.Name(),
+		Description: descriptor.Description(),
+		Unit:        string(descriptor.Unit()),
+		Data: &metricpb.Metric_ExponentialHistogram{
+			ExponentialHistogram: &metricpb.ExponentialHistogram{
+				AggregationTemporality: temporality,
+				DataPoints: []*metricpb.ExponentialHistogramDataPoint{
+					{
+						Attributes:        encodeAttributes(&iterator),
+						StartTimeUnixNano: uint64(record.StartTime().UnixNano()),
+						TimeUnixNano:      uint64(record.EndTime().UnixNano()),
+						Count:             state.count,
+						Sum:               state.sum,
+						Scale:             state.scale,
+						ZeroCount:         state.zeroCount,
+						Positive:          encodeBuckets(state.positive),
+						Negative:          encodeBuckets(state.negative),
+					},
+				},
+			},
+		},
+	}
+}
+
+func encodeBuckets(buckets exponentialBuckets) *metricpb.ExponentialHistogramDataPoint_Buckets {
+	return &metricpb.ExponentialHistogramDataPoint_Buckets{Offset: buckets.offset, BucketCounts: buckets.counts}
+}
+
+func encodeAttributes(iterator *attribute.Iterator) []*commonpb.KeyValue {
+	attrs := make([]*commonpb.KeyValue, 0, iterator.Len())
+	for iterator.Next() {
+		attr := iterator.Attribute()
+		attrs = append(attrs, &commonpb.KeyValue{Key: string(attr.Key), Value: encodeValue(attr.Value)})
+	}
+	return attrs
+}
+
+func encodeValue(value attribute.Value) *commonpb.AnyValue {
+	switch value.Type() {
+	case attribute.BOOL:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: value.AsBool()}}
+	case attribute.INT64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: value.AsInt64()}}
+	case attribute.FLOAT64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: value.AsFloat64()}}
+	case attribute.STRING:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value.AsString()}}
 	default:
-		return ""
+		// Slices are rare on metrics, their JSON encoding keeps them readable
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value.Emit()}}
 	}
 }
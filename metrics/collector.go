@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/garden/observability-commons/config"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/unit"
+)
+
+// asyncInstruments creates the asynchronous instruments of a collector through their views, so they can all be
+// observed from the collector's single callback.
+type asyncInstruments struct {
+	meter        metric.Meter
+	views        *viewResolver
+	defaultAttrs []attribute.KeyValue
+
+	created []instrument.Asynchronous
+}
+
+// collectedInstrument is an asynchronous instrument of a collector with the default attributes its view keeps. A nil
+// observer means the view drops it or its source isn't available.
+type collectedInstrument[N numeric] struct {
+	observer observer[N]
+	attrs    []attribute.KeyValue
+}
+
+type asynchronousObserver[N numeric] interface {
+	observer[N]
+	instrument.Asynchronous
+}
+
+// asynchronousFunc creates an asynchronous instrument of the given kind, e.g. a gauge or a counter.
+type asynchronousFunc[N numeric] func(meter metric.Meter, name string, options ...instrument.Option) (asynchronousObserver[N], error)
+
+func newAsyncInstruments(meter metric.Meter, views *viewResolver, defaultAttrs []attribute.KeyValue) *asyncInstruments {
+	return &asyncInstruments{meter: meter, views: views, defaultAttrs: defaultAttrs}
+}
+
+func newCollectedInstrument[N numeric](instruments *asyncInstruments, name string, instrumentUnit unit.Unit, create asynchronousFunc[N]) (collectedInstrument[N], error) {
+	view := instruments.views.resolve(name)
+	if view.aggregation == config.DropAggregation {
+		return collectedInstrument[N]{}, nil
+	}
+
+	options := view.options()
+	if len(options) == 0 {
+		options = []instrument.Option{instrument.WithUnit(instrumentUnit)}
+	}
+	created, err := create(instruments.meter, view.name, options...)
+	if err != nil {
+		return collectedInstrument[N]{}, err
+	}
+
+	instruments.created = append(instruments.created, created)
+	attrs := append([]attribute.KeyValue{}, instruments.defaultAttrs...)
+	return collectedInstrument[N]{observer: created, attrs: view.filter(attrs)}, nil
+}
+
+// register observes every created instrument from callback.
+func (instruments *asyncInstruments) register(callback func(ctx context.Context)) error {
+	if len(instruments.created) == 0 {
+		return nil
+	}
+	return instruments.meter.RegisterCallback(instruments.created, callback)
+}
+
+func (collected collectedInstrument[N]) observe(ctx context.Context, value N, attrs ...attribute.KeyValue) {
+	if collected.observer == nil {
+		return
+	}
+	collected.observer.Observe(ctx, value, append(collected.attrs[:len(collected.attrs):len(collected.attrs)], attrs...)...)
+}
+
+func int64Gauge(meter metric.Meter, name string, options ...instrument.Option) (asynchronousObserver[int64], error) {
+	return meter.AsyncInt64().Gauge(name, options...)
+}
+
+func int64Counter(meter metric.Meter, name string, options ...instrument.Option) (asynchronousObserver[int64], error) {
+	return meter.AsyncInt64().Counter(name, options...)
+}
+
+func float64Gauge(meter metric.Meter, name string, options ...instrument.Option) (asynchronousObserver[float64], error) {
+	return meter.AsyncFloat64().Gauge(name, options...)
+}
+
+func float64Counter(meter metric.Meter, name string, options ...instrument.Option) (asynchronousObserver[float64], error) {
+	return meter.AsyncFloat64().Counter(name, options...)
+}
@@ -0,0 +1,289 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+
+	"go.opentelemetry.io/otel/sdk/metric/aggregator"
+	"go.opentelemetry.io/otel/sdk/metric/export/aggregation"
+	"go.opentelemetry.io/otel/sdk/metric/number"
+	"go.opentelemetry.io/otel/sdk/metric/sdkapi"
+)
+
+// exponentialHistogramKind is not one of the SDK's aggregation kinds, its exporters skip it and exponentialExporter
+// encodes it instead.
+const exponentialHistogramKind aggregation.Kind = "ExponentialHistogram"
+
+var errNonFiniteValue = errors.New("exponential histograms only record finite values")
+
+// exponentialAggregator aggregates measurements into base-2 exponential buckets. At scale s, bucket i holds the values
+// in (2^(i/2^s), 2^((i+1)/2^s)]. It starts at the max scale and halves its resolution whenever the recorded values
+// need more than maxSize buckets of the same sign.
+type exponentialAggregator struct {
+	maxScale int32
+	maxSize  int32
+
+	lock  sync.Mutex
+	state exponentialState
+}
+
+type exponentialState struct {
+	scale     int32
+	count     uint64
+	sum       float64
+	zeroCount uint64
+	positive  exponentialBuckets
+	negative  exponentialBuckets
+}
+
+// exponentialBuckets holds the counts of the buckets from index offset onwards.
+type exponentialBuckets struct {
+	offset int32
+	counts []uint64
+}
+
+var _ aggregator.Aggregator = &exponentialAggregator{}
+
+func newExponentialAggregators(cnt int, maxScale, maxSize int32) []exponentialAggregator {
+	// Config.Ensure rejects fewer than 2 buckets, without which no scale can make the indexes fit
+	maxSize = maxInt32(maxSize, 2)
+	aggs := make([]exponentialAggregator, cnt)
+	for i := range aggs {
+		aggs[i] = exponentialAggregator{
+			maxScale: maxScale,
+			maxSize:  maxSize,
+			state:    exponentialState{scale: maxScale},
+		}
+	}
+	return aggs
+}
+
+func (agg *exponentialAggregator) Aggregation() aggregation.Aggregation {
+	return agg
+}
+
+func (agg *exponentialAggregator) Kind() aggregation.Kind {
+	return exponentialHistogramKind
+}
+
+// snapshot returns a copy of the aggregated state, safe to read while values keep being recorded.
+func (agg *exponentialAggregator) snapshot() exponentialState {
+	agg.lock.Lock()
+	defer agg.lock.Unlock()
+
+	return agg.state.clone()
+}
+
+func (agg *exponentialAggregator) Update(_ context.Context, value number.Number, descriptor *sdkapi.Descriptor) error {
+	floatValue := value.CoerceToFloat64(descriptor.NumberKind())
+	if math.IsNaN(floatValue) || math.IsInf(floatValue, 0) {
+		return errNonFiniteValue
+	}
+
+	agg.lock.Lock()
+	defer agg.lock.Unlock()
+
+	agg.state.record(floatValue, agg.maxSize)
+	return nil
+}
+
+func (agg *exponentialAggregator) SynchronizedMove(destination aggregator.Aggregator, _ *sdkapi.Descriptor) error {
+	other, ok := destination.(*exponentialAggregator)
+	if !ok && destination != nil {
+		return aggregator.NewInconsistentAggregatorError(agg, destination)
+	}
+
+	agg.lock.Lock()
+	defer agg.lock.Unlock()
+
+	if other != nil {
+		other.state = agg.state
+	}
+	agg.state = exponentialState{scale: agg.maxScale}
+	return nil
+}
+
+func (agg *exponentialAggregator) Merge(source aggregator.Aggregator, _ *sdkapi.Descriptor) error {
+	other, ok := source.(*exponentialAggregator)
+	if !ok {
+		return aggregator.NewInconsistentAggregatorError(agg, source)
+	}
+
+	otherState := other.snapshot()
+
+	agg.lock.Lock()
+	defer agg.lock.Unlock()
+
+	agg.state.merge(otherState, agg.maxSize)
+	return nil
+}
+
+func (state *exponentialState) record(value float64, maxSize int32) {
+	state.count++
+	state.sum += value
+
+	buckets := &state.positive
+	switch {
+	case value == 0:
+		state.zeroCount++
+		return
+	case value < 0:
+		buckets = &state.negative
+		value = -value
+	}
+
+	index := mapToIndex(value, state.scale)
+	low, high := buckets.rangeWith(index, index)
+	if change := scaleChange(low, high, maxSize); change > 0 {
+		state.downscale(change)
+		index = mapToIndex(value, state.scale)
+	}
+	buckets.add(index, 1)
+}
+
+// merge adds other to the state, lowering the scale to the coarser of both and then as much as needed to fit the
+// combined buckets within maxSize.
+func (state *exponentialState) merge(other exponentialState, maxSize int32) {
+	if other.count == 0 {
+		return
+	}
+
+	scale := state.scale
+	if other.scale < scale {
+		scale = other.scale
+	}
+	for _, pair := range [][2]*exponentialBuckets{{&state.positive, &other.positive}, {&state.negative, &other.negative}} {
+		buckets, otherBuckets := pair[0], pair[1]
+		if len(otherBuckets.counts) == 0 {
+			continue
+		}
+
+		low, high := otherBuckets.bounds(other.scale - scale)
+		if len(buckets.counts) > 0 {
+			ownLow, ownHigh := buckets.bounds(state.scale - scale)
+			low, high = minInt32(low, ownLow), maxInt32(high, ownHigh)
+		}
+		scale -= scaleChange(low, high, maxSize)
+	}
+
+	state.downscale(state.scale - scale)
+	for _, pair := range [][2]*exponentialBuckets{{&state.positive, &other.positive}, {&state.negative, &other.negative}} {
+		buckets, otherBuckets := pair[0], pair[1]
+		for i, count := range otherBuckets.counts {
+			if count > 0 {
+				buckets.add((otherBuckets.offset+int32(i))>>(other.scale-scale), count)
+			}
+		}
+	}
+
+	state.count += other.count
+	state.sum += other.sum
+	state.zeroCount += other.zeroCount
+}
+
+func (state *exponentialState) downscale(change int32) {
+	if change <= 0 {
+		return
+	}
+
+	state.scale -= change
+	state.positive.downscale(change)
+	state.negative.downscale(change)
+}
+
+func (state exponentialState) clone() exponentialState {
+	state.positive.counts = append([]uint64(nil), state.positive.counts...)
+	state.negative.counts = append([]uint64(nil), state.negative.counts...)
+	return state
+}
+
+// bounds returns the lowest and highest bucket indexes once the scale is lowered by change.
+func (buckets *exponentialBuckets) bounds(change int32) (int32, int32) {
+	return buckets.offset >> change, (buckets.offset + int32(len(buckets.counts)) - 1) >> change
+}
+
+// rangeWith returns the lowest and highest bucket indexes once low and high are included.
+func (buckets *exponentialBuckets) rangeWith(low, high int32) (int32, int32) {
+	if len(buckets.counts) == 0 {
+		return low, high
+	}
+	ownLow, ownHigh := buckets.bounds(0)
+	return minInt32(low, ownLow), maxInt32(high, ownHigh)
+}
+
+func (buckets *exponentialBuckets) add(index int32, count uint64) {
+	switch {
+	case len(buckets.counts) == 0:
+		buckets.offset = index
+		buckets.counts = append(buckets.counts, 0)
+	case index < buckets.offset:
+		grown := make([]uint64, int(buckets.offset-index)+len(buckets.counts))
+		copy(grown[buckets.offset-index:], buckets.counts)
+		buckets.counts = grown
+		buckets.offset = index
+	case index >= buckets.offset+int32(len(buckets.counts)):
+		buckets.counts = append(buckets.counts, make([]uint64, int(index-buckets.offset)-len(buckets.counts)+1)...)
+	}
+	buckets.counts[index-buckets.offset] += count
+}
+
+// downscale merges every 2^change neighbouring buckets into one.
+func (buckets *exponentialBuckets) downscale(change int32) {
+	if len(buckets.counts) == 0 {
+		return
+	}
+
+	offset, last := buckets.bounds(change)
+	counts := make([]uint64, last-offset+1)
+	for i, count := range buckets.counts {
+		counts[((buckets.offset+int32(i))>>change)-offset] += count
+	}
+	buckets.offset = offset
+	buckets.counts = counts
+}
+
+// scaleChange returns by how much the scale has to be lowered for the bucket indexes from low to high to fit within
+// maxSize buckets.
+func scaleChange(low, high, maxSize int32) int32 {
+	var change int32
+	for high-low >= maxSize {
+		low >>= 1
+		high >>= 1
+		change++
+	}
+	return change
+}
+
+// mapToIndex returns the index of the bucket holding the positive value at the given scale. Exact powers of two are
+// computed from the float's exponent, since they are bucket boundaries and logarithms may round them to the next one.
+func mapToIndex(value float64, scale int32) int32 {
+	frac, exp := math.Frexp(value)
+	powerOfTwo := frac == 0.5
+	if scale <= 0 {
+		if powerOfTwo {
+			exp--
+		}
+		return int32(exp-1) >> -scale
+	}
+
+	if powerOfTwo {
+		return (int32(exp-1) << scale) - 1
+	}
+	return int32(math.Ceil(math.Log(value)*math.Ldexp(math.Log2E, int(scale)))) - 1
+}
+
+func minInt32(a, b int32) int32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,189 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/unit"
+	"go.opentelemetry.io/otel/sdk/metric/export"
+	"go.opentelemetry.io/otel/sdk/metric/export/aggregation"
+	"go.opentelemetry.io/otel/sdk/metric/number"
+	"go.opentelemetry.io/otel/sdk/metric/sdkapi"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+func TestMapToIndex(t *testing.T) {
+	tests := []struct {
+		name  string
+		value float64
+		scale int32
+		want  int32
+	}{
+		{name: "one at scale 0", value: 1, scale: 0, want: -1},
+		{name: "two at scale 0", value: 2, scale: 0, want: 0},
+		{name: "three at scale 0", value: 3, scale: 0, want: 1},
+		{name: "four at scale 0", value: 4, scale: 0, want: 1},
+		{name: "just above four at scale 0", value: 4.000001, scale: 0, want: 2},
+		{name: "half at scale 0", value: 0.5, scale: 0, want: -2},
+		{name: "four at scale -1", value: 4, scale: -1, want: 0},
+		{name: "five at scale -1", value: 5, scale: -1, want: 1},
+		{name: "one at scale -1", value: 1, scale: -1, want: -1},
+		{name: "1000 at scale -2", value: 1000, scale: -2, want: 2},
+		{name: "two at scale 1", value: 2, scale: 1, want: 1},
+		{name: "1.5 at scale 1", value: 1.5, scale: 1, want: 1},
+		{name: "1.4 at scale 1", value: 1.4, scale: 1, want: 0},
+		{name: "1.1 at scale 3", value: 1.1, scale: 3, want: 1},
+		{name: "100 at scale 3", value: 100, scale: 3, want: 53},
+		{name: "1024 at scale 20", value: 1024, scale: 20, want: 10<<20 - 1},
+		{name: "max float at scale 0", value: math.MaxFloat64, scale: 0, want: 1023},
+		{name: "smallest normal at scale 0", value: 0x1p-1022, scale: 0, want: -1023},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, mapToIndex(tt.value, tt.scale))
+		})
+	}
+}
+
+func TestExponentialAggregator_Update(t *testing.T) {
+	descriptor := sdkapi.NewDescriptor("request.duration", sdkapi.HistogramInstrumentKind, number.Float64Kind, "", "")
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		maxScale int32
+		maxSize  int32
+		values   []float64
+		want     exponentialState
+	}{
+		{
+			name:     "positive, negative and zero values",
+			maxScale: 0,
+			maxSize:  160,
+			values:   []float64{1, 2, 3, 4, 0, -3},
+			want: exponentialState{
+				scale:     0,
+				count:     6,
+				sum:       7,
+				zeroCount: 1,
+				positive:  exponentialBuckets{offset: -1, counts: []uint64{1, 1, 2}},
+				negative:  exponentialBuckets{offset: 1, counts: []uint64{1}},
+			},
+		},
+		{
+			name:     "downscales to fit max size",
+			maxScale: 2,
+			maxSize:  4,
+			values:   []float64{1, 2, 4, 8},
+			// At scale 2 the values span indexes -1 to 11, at scale 0 they fit in -1 to 2
+			want: exponentialState{
+				scale:    0,
+				count:    4,
+				sum:      15,
+				positive: exponentialBuckets{offset: -1, counts: []uint64{1, 1, 1, 1}},
+			},
+		},
+		{
+			name:     "downscales below zero",
+			maxScale: 0,
+			maxSize:  2,
+			values:   []float64{1, 1000},
+			want: exponentialState{
+				scale:    -4,
+				count:    2,
+				sum:      1001,
+				positive: exponentialBuckets{offset: -1, counts: []uint64{1, 1}},
+			},
+		},
+		{
+			name:     "max size without Ensure",
+			maxScale: 0,
+			maxSize:  0,
+			values:   []float64{1, 1000},
+			want: exponentialState{
+				scale:    -4,
+				count:    2,
+				sum:      1001,
+				positive: exponentialBuckets{offset: -1, counts: []uint64{1, 1}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agg := &newExponentialAggregators(1, tt.maxScale, tt.maxSize)[0]
+			for _, value := range tt.values {
+				assert.NoError(t, agg.Update(ctx, number.NewFloat64Number(value), &descriptor))
+			}
+			assert.Equal(t, tt.want, agg.snapshot())
+		})
+	}
+
+	agg := &newExponentialAggregators(1, 0, 160)[0]
+	assert.Error(t, agg.Update(ctx, number.NewFloat64Number(math.NaN()), &descriptor))
+	assert.Error(t, agg.Update(ctx, number.NewFloat64Number(math.Inf(1)), &descriptor))
+	assert.Zero(t, agg.snapshot().count)
+}
+
+func TestExponentialAggregator_SynchronizedMoveAndMerge(t *testing.T) {
+	descriptor := sdkapi.NewDescriptor("request.duration", sdkapi.HistogramInstrumentKind, number.Int64Kind, "", "")
+	ctx := context.Background()
+	aggs := newExponentialAggregators(3, 1, 4)
+	current, checkpoint, cumulative := &aggs[0], &aggs[1], &aggs[2]
+
+	assert.NoError(t, current.Update(ctx, number.NewInt64Number(2), &descriptor))
+	assert.NoError(t, current.Update(ctx, number.NewInt64Number(3), &descriptor))
+	assert.NoError(t, current.SynchronizedMove(checkpoint, &descriptor))
+	assert.Equal(t, exponentialState{scale: 1}, current.snapshot(), "moving resets to the max scale")
+	assert.NoError(t, cumulative.Merge(checkpoint, &descriptor))
+
+	// 64 needs index 11 at scale 1, too far from 2 and 3, so the merge lowers the scale to fit both
+	assert.NoError(t, current.Update(ctx, number.NewInt64Number(64), &descriptor))
+	assert.NoError(t, current.SynchronizedMove(checkpoint, &descriptor))
+	assert.NoError(t, cumulative.Merge(checkpoint, &descriptor))
+
+	assert.Equal(t, exponentialState{
+		scale:    -1,
+		count:    3,
+		sum:      69,
+		positive: exponentialBuckets{offset: 0, counts: []uint64{2, 0, 1}},
+	}, cumulative.snapshot())
+}
+
+func TestEncodeExponentialHistogram(t *testing.T) {
+	descriptor := sdkapi.NewDescriptor("request.duration", sdkapi.HistogramInstrumentKind, number.Float64Kind, "", unit.Milliseconds)
+	agg := &newExponentialAggregators(1, 0, 160)[0]
+	for _, value := range []float64{3, 4, -1} {
+		assert.NoError(t, agg.Update(context.Background(), number.NewFloat64Number(value), &descriptor))
+	}
+	attrs := attribute.NewSet(attribute.String("service", "checkout"))
+	start := time.Unix(100, 0)
+	end := time.Unix(130, 0)
+	record := export.NewRecord(&descriptor, &attrs, agg, start, end)
+
+	got := encodeExponentialHistogram(record, agg.snapshot(), aggregation.DeltaTemporalitySelector())
+
+	assert.Equal(t, "request.duration", got.Name)
+	assert.Equal(t, "ms", got.Unit)
+	histogram := got.GetExponentialHistogram()
+	if assert.NotNil(t, histogram) && assert.Len(t, histogram.DataPoints, 1) {
+		assert.Equal(t, metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA, histogram.AggregationTemporality)
+		point := histogram.DataPoints[0]
+		assert.Equal(t, uint64(start.UnixNano()), point.StartTimeUnixNano)
+		assert.Equal(t, uint64(end.UnixNano()), point.TimeUnixNano)
+		assert.Equal(t, uint64(3), point.Count)
+		assert.Equal(t, 6.0, point.Sum)
+		assert.Equal(t, int32(0), point.Scale)
+		assert.Equal(t, int32(1), point.Positive.Offset)
+		assert.Equal(t, []uint64{2}, point.Positive.BucketCounts)
+		assert.Equal(t, int32(-1), point.Negative.Offset)
+		assert.Equal(t, []uint64{1}, point.Negative.BucketCounts)
+		if assert.Len(t, point.Attributes, 1) {
+			assert.Equal(t, "service", point.Attributes[0].Key)
+			assert.Equal(t, "checkout", point.Attributes[0].Value.GetStringValue())
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/garden/observability-commons/config"
+	"github.com/garden/observability-commons/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOtelMeter_DefaultGauge_ReportsLatestValue(t *testing.T) {
+	collecting := newFakeMeter()
+	meter := newTestMeter(collecting, config.Config{})
+	ctx := context.Background()
+
+	for _, value := range []int64{3, 7, 5} {
+		assert.NoError(t, meter.DefaultGauge(ctx, "orders.active", value, util.ExtraFields{"region": "eu"}))
+	}
+	assert.NoError(t, meter.DefaultGauge(ctx, "orders.active", 2, util.ExtraFields{"region": "us"}))
+
+	assert.Len(t, collecting.callbacks, 1, "a gauge must register a single callback")
+
+	for i := 0; i < 2; i++ {
+		observations := collecting.collect()["orders.active"]
+		if assert.Len(t, observations, 2) {
+			values := map[string]float64{}
+			for _, observed := range observations {
+				values[observed.attrs["region"]] = observed.value
+			}
+			assert.Equal(t, map[string]float64{"eu": 5, "us": 2}, values)
+		}
+	}
+
+	assert.NoError(t, meter.DefaultGauge(ctx, "orders.active", 9, util.ExtraFields{"region": "eu"}))
+	observations := collecting.collect()["orders.active"]
+	for _, observed := range observations {
+		if observed.attrs["region"] == "eu" {
+			assert.Equal(t, float64(9), observed.value)
+		}
+	}
+}
+
+func TestOtelMeter_RemoveGauge(t *testing.T) {
+	collecting := newFakeMeter()
+	meter := newTestMeter(collecting, config.Config{})
+	ctx := context.Background()
+
+	assert.NoError(t, meter.DefaultGauge(ctx, "connections.active", 4, util.ExtraFields{"pool": "primary"}))
+	assert.NoError(t, meter.DefaultGauge(ctx, "connections.active", 1, util.ExtraFields{"pool": "replica"}))
+
+	meter.RemoveGauge("connections.active", util.ExtraFields{"pool": "replica"})
+	meter.RemoveGauge("unknown.gauge", util.ExtraFields{"pool": "replica"})
+
+	observations := collecting.collect()["connections.active"]
+	if assert.Len(t, observations, 1) {
+		assert.Equal(t, "primary", observations[0].attrs["pool"])
+		assert.Equal(t, float64(4), observations[0].value)
+	}
+}
+
+func TestOtelMeter_DefaultFloat64Gauge(t *testing.T) {
+	collecting := newFakeMeter()
+	meter := newTestMeter(collecting, config.Config{})
+	ctx := context.Background()
+
+	assert.NoError(t, meter.DefaultFloat64Gauge(ctx, "cpu.ratio", 0.25, util.ExtraFields{"core": "0"}))
+	assert.NoError(t, meter.DefaultFloat64Gauge(ctx, "cpu.ratio", 0.75, util.ExtraFields{"core": "0"}))
+
+	observations := collecting.collect()["cpu.ratio"]
+	if assert.Len(t, observations, 1) {
+		assert.Equal(t, 0.75, observations[0].value)
+	}
+
+	meter.RemoveGauge("cpu.ratio", util.ExtraFields{"core": "0"})
+	assert.Empty(t, collecting.collect()["cpu.ratio"])
+}
@@ -3,6 +3,7 @@ package metrics
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 
 	"github.com/garden/observability-commons/config"
@@ -12,129 +13,319 @@ import (
 	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/metric/global"
-	"go.opentelemetry.io/otel/metric/instrument"
-	"go.opentelemetry.io/otel/sdk/metric/aggregator/histogram"
+	"go.opentelemetry.io/otel/metric/unit"
 	controller "go.opentelemetry.io/otel/sdk/metric/controller/basic"
 	"go.opentelemetry.io/otel/sdk/metric/export"
-	"go.opentelemetry.io/otel/sdk/metric/export/aggregation"
 	processor "go.opentelemetry.io/otel/sdk/metric/processor/basic"
-	selector "go.opentelemetry.io/otel/sdk/metric/selector/simple"
 	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
 )
 
 const (
 	instrumentationName = "github.com/garden/observability-commons"
 )
 
-var (
-	defaultHistogramBuckets []float64
-)
-
 type Meter interface {
 	DefaultHistogram(ctx context.Context, metricName string, value float64, fields util.ExtraFields) error
+	DefaultInt64Histogram(ctx context.Context, metricName string, value int64, fields util.ExtraFields) error
+	RegisterHistogram(metricName string, unit string, boundaries []float64) error
 	DefaultGauge(ctx context.Context, metricName string, value int64, fields util.ExtraFields) error
+	DefaultFloat64Gauge(ctx context.Context, metricName string, value float64, fields util.ExtraFields) error
 	DefaultCounter(ctx context.Context, metricName string, value int64, fields util.ExtraFields) error
+	DefaultFloat64Counter(ctx context.Context, metricName string, value float64, fields util.ExtraFields) error
+	DefaultUpDownCounter(ctx context.Context, metricName string, value int64, fields util.ExtraFields) error
+	DefaultFloat64UpDownCounter(ctx context.Context, metricName string, value float64, fields util.ExtraFields) error
+	RemoveGauge(metricName string, fields util.ExtraFields)
+	RegisterGaugeFunc(metricName string, function func(ctx context.Context) int64, fields util.ExtraFields) (Unregister, error)
+	RegisterCounterFunc(metricName string, function func(ctx context.Context) int64, fields util.ExtraFields) (Unregister, error)
+	PrometheusHandler() http.Handler
+	RejectedAttributeSets() uint64
+	Close() error
 }
 
 type OtelMeter struct {
-	meter metric.Meter
-	cfg   config.Config
+	meter        metric.Meter
+	ctrl         *controller.Controller
+	cfg          config.Config
+	instruments  *instrumentRegistry
+	views        *viewResolver
+	defaultAttrs []attribute.KeyValue
+	keys         keyFilter
+	limiter      *cardinalityLimiter
 }
 
 func NewOtelMeter(cfg config.Config) (*OtelMeter, error) {
 	ctx := context.Background()
 
+	views := newViewResolver(cfg)
+	serviceResource := cfg.GetResource()
+	temporality := newTemporalitySelector(cfg.MetricTemporality)
+
 	var exporter export.Exporter
 	var err error
 	switch cfg.Mode {
 	case config.Noop:
-		return &OtelMeter{
-			meter: metric.NewNoopMeter(),
-		}, nil
+		return newOtelMeter(metric.NewNoopMeter(), nil, cfg, serviceResource, views), nil
 	case config.Local:
+		if !cfg.MetricExporter.PushesOTLP() {
+			break
+		}
 		exporter, err = stdoutmetric.New(stdoutmetric.WithPrettyPrint())
 		if err != nil {
 			return nil, fmt.Errorf("error creating otel exporter: %w", err)
 		}
+		exporter = newExponentialExporter(exporter, temporality, printResourceMetrics)
 	case config.Debug, config.Development, config.Production:
-		exporter, err = otlpmetric.New(ctx, newClient(cfg))
+		if !cfg.MetricExporter.PushesOTLP() {
+			break
+		}
+		client := newClient(cfg)
+		exporter, err = otlpmetric.New(ctx, client, otlpmetric.WithMetricAggregationTemporalitySelector(temporality))
 		if err != nil {
 			return nil, fmt.Errorf("error creating otel exporter: %w", err)
 		}
+		exporter = newExponentialExporter(exporter, temporality, client.UploadMetrics)
 	default:
 		return nil, fmt.Errorf("error creating otel meter: unknown mode %v", cfg.Mode)
 	}
 
+	res, err := resource.Merge(serviceResource, resource.NewSchemaless(attribute.Key("metric.category").String("system")))
+	if err != nil {
+		return nil, fmt.Errorf("error creating otel meter: %w", err)
+	}
+
+	options := []controller.Option{
+		controller.WithResource(res),
+	}
+	if exporter != nil {
+		options = append(options, controller.WithExporter(exporter), controller.WithCollectPeriod(cfg.FlushInterval))
+	} else {
+		// Every Prometheus scrape collects
+		options = append(options, controller.WithCollectPeriod(0))
+	}
+
 	ctrl := controller.New(
 		processor.NewFactory(
-			selector.NewWithHistogramDistribution(histogram.WithExplicitBoundaries(defaultHistogramBuckets)),
-			aggregation.CumulativeTemporalitySelector(),
-			processor.WithMemory(true),
+			aggregatorSelector{resolver: views},
+			temporality,
+			// Without memory, series not updated during an interval are forgotten, cumulative ones must be kept
+			processor.WithMemory(cfg.MetricTemporality == config.Cumulative),
 		),
-		controller.WithExporter(exporter),
-		controller.WithCollectPeriod(cfg.FlushInterval),
-		controller.WithResource(resource.NewWithAttributes(instrumentationName, attribute.Key("metric.category").String("system"))),
+		options...,
 	)
-	if err = ctrl.Start(ctx); err != nil {
-		return nil, fmt.Errorf("error starting push controller: %w", err)
+	// Published before anything starts, so a failure leaves no controller running nor global provider behind
+	meter := newOtelMeter(ctrl.Meter(instrumentationName), ctrl, cfg, serviceResource, views)
+	if err = meter.publishBuildInfo(util.ReadBuildInfo()); err != nil {
+		return nil, err
+	}
+
+	// Only pushes run the controller, which then refuses to collect on demand
+	if exporter != nil {
+		if err = ctrl.Start(ctx); err != nil {
+			return nil, fmt.Errorf("error starting push controller: %w", err)
+		}
 	}
 
 	global.SetMeterProvider(ctrl)
+	return meter, nil
+}
+
+func newOtelMeter(meter metric.Meter, ctrl *controller.Controller, cfg config.Config, res *resource.Resource, views *viewResolver) *OtelMeter {
 	return &OtelMeter{
-		meter: global.Meter(instrumentationName),
-		cfg:   cfg,
-	}, nil
+		meter:        meter,
+		ctrl:         ctrl,
+		cfg:          cfg,
+		instruments:  newInstrumentRegistry(meter, views),
+		views:        views,
+		defaultAttrs: newDefaultAttrs(cfg, res),
+		keys:         newKeyFilter(cfg.MetricAttributeAllowList, cfg.MetricAttributeDenyList),
+		limiter:      newCardinalityLimiter(cfg.MetricCardinalityLimit),
+	}
+}
+
+// Close collects and exports the last values and stops the push controller, giving up after cfg.Timeout.
+func (meter OtelMeter) Close() error {
+	if meter.ctrl == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), meter.cfg.Timeout)
+	defer cancel()
+
+	return meter.ctrl.Stop(ctx)
+}
+
+// PrometheusHandler serves the meter's instruments to Prometheus scrapes, with dots and other invalid characters of
+// metric and attribute names replaced by underscores. It answers 404 unless cfg.MetricExporter serves Prometheus.
+func (meter OtelMeter) PrometheusHandler() http.Handler {
+	if meter.ctrl == nil || !meter.cfg.MetricExporter.ServesPrometheus() {
+		return http.NotFoundHandler()
+	}
+	return prometheusHandler{ctrl: meter.ctrl, timeout: meter.cfg.Timeout, collect: !meter.cfg.MetricExporter.PushesOTLP()}
 }
 
 func (meter OtelMeter) DefaultHistogram(ctx context.Context, metricName string, value float64, fields util.ExtraFields) error {
-	h, err := meter.meter.SyncFloat64().Histogram(metricName)
+	h, err := meter.instruments.histogram(metricName)
+	if err != nil {
+		return err
+	}
+	h.Record(ctx, value, meter.attrs(metricName, fields)...)
+	return nil
+}
+
+// RegisterHistogram sets the unit and bucket boundaries of a histogram. It must be called before the first value is
+// recorded; views matching the histogram still take precedence.
+func (meter OtelMeter) RegisterHistogram(metricName string, histogramUnit string, boundaries []float64) error {
+	return meter.views.defineHistogram(metricName, unit.Unit(histogramUnit), boundaries)
+}
+
+func (meter OtelMeter) DefaultInt64Histogram(ctx context.Context, metricName string, value int64, fields util.ExtraFields) error {
+	h, err := meter.instruments.int64Histogram(metricName)
 	if err != nil {
 		return err
 	}
-	h.Record(ctx, value, append(fields.ToAttrs(), meter.defaultAttrs()...)...)
+	h.Record(ctx, value, meter.attrs(metricName, fields)...)
 	return nil
 }
 
 func (meter OtelMeter) DefaultGauge(ctx context.Context, metricName string, value int64, fields util.ExtraFields) error {
-	gauge, err := meter.meter.AsyncInt64().Gauge(metricName)
+	gauge, err := meter.instruments.gauge(metricName)
 	if err != nil {
 		return err
 	}
 
-	if err := meter.meter.RegisterCallback(
-		[]instrument.Asynchronous{
-			gauge,
-		},
-		func(ctx context.Context) {
-			gauge.Observe(ctx, value, append(fields.ToAttrs(), meter.defaultAttrs()...)...)
-		},
-	); err != nil {
+	gauge.set(value, meter.attrs(metricName, fields))
+	return nil
+}
+
+func (meter OtelMeter) DefaultFloat64Gauge(ctx context.Context, metricName string, value float64, fields util.ExtraFields) error {
+	gauge, err := meter.instruments.float64Gauge(metricName)
+	if err != nil {
 		return err
 	}
 
+	gauge.set(value, meter.attrs(metricName, fields))
 	return nil
 }
 
+// RemoveGauge stops reporting the series of the given int64 or float64 gauge identified by fields, e.g. once the
+// resource it tracks is gone.
+func (meter OtelMeter) RemoveGauge(metricName string, fields util.ExtraFields) {
+	attrs := meter.unlimitedAttrs(metricName, fields)
+	meter.limiter.release(metricName, attrs)
+	if gauge, ok := meter.instruments.lookup(metricName, gaugeKind); ok {
+		gauge.(*observableInstrument[int64]).remove(attrs)
+	}
+	if gauge, ok := meter.instruments.lookup(metricName, float64GaugeKind); ok {
+		gauge.(*observableInstrument[float64]).remove(attrs)
+	}
+}
+
+// RegisterGaugeFunc samples function on every collection and reports its result as the gauge's current value.
+func (meter OtelMeter) RegisterGaugeFunc(metricName string, function func(ctx context.Context) int64, fields util.ExtraFields) (Unregister, error) {
+	gauge, err := meter.instruments.gauge(metricName)
+	if err != nil {
+		return nil, err
+	}
+	return gauge.register(function, meter.attrs(metricName, fields)), nil
+}
+
+// RegisterCounterFunc samples function on every collection and reports its result as the counter's running total, so
+// it must never decrease.
+func (meter OtelMeter) RegisterCounterFunc(metricName string, function func(ctx context.Context) int64, fields util.ExtraFields) (Unregister, error) {
+	counter, err := meter.instruments.observableCounter(metricName)
+	if err != nil {
+		return nil, err
+	}
+	return counter.register(function, meter.attrs(metricName, fields)), nil
+}
+
 func (meter OtelMeter) DefaultCounter(ctx context.Context, metricName string, value int64, fields util.ExtraFields) error {
-	counter, err := meter.meter.SyncInt64().Counter(metricName)
+	counter, err := meter.instruments.counter(metricName)
+	if err != nil {
+		return err
+	}
+
+	counter.Add(ctx, value, meter.attrs(metricName, fields)...)
+	return nil
+}
+
+func (meter OtelMeter) DefaultFloat64Counter(ctx context.Context, metricName string, value float64, fields util.ExtraFields) error {
+	counter, err := meter.instruments.float64Counter(metricName)
+	if err != nil {
+		return err
+	}
+
+	counter.Add(ctx, value, meter.attrs(metricName, fields)...)
+	return nil
+}
+
+// DefaultUpDownCounter adds value, which may be negative, to a sum that can go up and down, like in-flight requests.
+func (meter OtelMeter) DefaultUpDownCounter(ctx context.Context, metricName string, value int64, fields util.ExtraFields) error {
+	counter, err := meter.instruments.upDownCounter(metricName)
+	if err != nil {
+		return err
+	}
+
+	counter.Add(ctx, value, meter.attrs(metricName, fields)...)
+	return nil
+}
+
+func (meter OtelMeter) DefaultFloat64UpDownCounter(ctx context.Context, metricName string, value float64, fields util.ExtraFields) error {
+	counter, err := meter.instruments.float64UpDownCounter(metricName)
 	if err != nil {
 		return err
 	}
 
-	counter.Add(ctx, value, append(fields.ToAttrs(), meter.defaultAttrs()...)...)
+	counter.Add(ctx, value, meter.attrs(metricName, fields)...)
 	return nil
 }
 
-func (meter OtelMeter) defaultAttrs() []attribute.KeyValue {
+// attrs returns the attributes of a measurement, the call-site fields followed by the precomputed default ones without
+// those dropped by the metric's view, or those of the metric's overflow series once the metric has reached
+// cfg.MetricCardinalityLimit.
+func (meter OtelMeter) attrs(metricName string, fields util.ExtraFields) []attribute.KeyValue {
+	attrs := meter.unlimitedAttrs(metricName, fields)
+	if meter.limiter.admit(metricName, attrs) {
+		return attrs
+	}
+
+	overflow := make([]attribute.KeyValue, 0, len(meter.defaultAttrs)+1)
+	overflow = append(overflow, meter.defaultAttrs...)
+	return meter.views.resolve(metricName).filter(append(overflow, overflowAttribute.Bool(true)))
+}
+
+func (meter OtelMeter) unlimitedAttrs(metricName string, fields util.ExtraFields) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(fields)+len(meter.defaultAttrs))
+	for key, value := range fields {
+		if meter.keys.allows(key) {
+			attrs = append(attrs, attribute.Key(key).String(value))
+		}
+	}
+	return meter.views.resolve(metricName).filter(append(attrs, meter.defaultAttrs...))
+}
+
+// RejectedAttributeSets returns how many distinct attribute sets were recorded into an overflow series because their
+// metric had reached cfg.MetricCardinalityLimit.
+func (meter OtelMeter) RejectedAttributeSets() uint64 {
+	return meter.limiter.rejectedSets()
+}
+
+// newDefaultAttrs is computed once per meter, since neither the config nor garden_STACK change while it runs. The app
+// name and version are those of the resource, which carries the config's service.
+func newDefaultAttrs(cfg config.Config, res *resource.Resource) []attribute.KeyValue {
 	stackName := getStackName()
+	attrs := res.Set()
+	name, _ := attrs.Value(semconv.ServiceNameKey)
+	version, _ := attrs.Value(semconv.ServiceVersionKey)
 	defaultAttr := []attribute.KeyValue{
-		attribute.Key("garden.app.name").String(meter.cfg.Service.Name),
-		attribute.Key("garden.app.version").String(meter.cfg.Service.Version),
+		attribute.Key("garden.app.name").String(name.AsString()),
+		attribute.Key("garden.app.version").String(version.AsString()),
 		attribute.Key("garden.stack").String(stackName),
 	}
 
-	if meter.cfg.DefaultFields != nil {
-		for fieldName, value := range *meter.cfg.DefaultFields {
+	if cfg.DefaultFields != nil {
+		for fieldName, value := range *cfg.DefaultFields {
 			defaultAttr = append(defaultAttr,
 				attribute.Key(fieldName).String(value))
 		}
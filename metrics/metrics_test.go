@@ -0,0 +1,387 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/garden/observability-commons/config"
+	"github.com/garden/observability-commons/util"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/asyncfloat64"
+	"go.opentelemetry.io/otel/metric/instrument/asyncint64"
+	"go.opentelemetry.io/otel/metric/instrument/syncfloat64"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+)
+
+func newTestMeter(meter metric.Meter, cfg config.Config) *OtelMeter {
+	return newOtelMeter(meter, nil, cfg, cfg.GetResource(), newViewResolver(cfg))
+}
+
+func benchmarkMeter() *OtelMeter {
+	return newTestMeter(metric.NewNoopMeter(), config.Config{
+		Service: config.Service{
+			Name:    "bench-service",
+			Version: "1.0.0",
+		},
+		DefaultFields: &map[string]string{"team": "payments"},
+	})
+}
+
+func TestOtelMeter_attrs(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantName    string
+		wantVersion string
+	}{
+		{name: "config", wantName: "bench-service", wantVersion: "1.0.0"},
+		{
+			name:        "the config overrides the resource variables",
+			env:         map[string]string{"OTEL_SERVICE_NAME": "orders", "OTEL_RESOURCE_ATTRIBUTES": "service.version=2.0.0"},
+			wantName:    "bench-service",
+			wantVersion: "1.0.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("garden_STACK", "blue")
+			t.Setenv("OTEL_SERVICE_NAME", "")
+			t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			meter := benchmarkMeter()
+
+			attrs := meter.attrs("request.count", util.ExtraFields{"route": "/orders"})
+
+			assert.Equal(t, []attribute.KeyValue{
+				attribute.String("route", "/orders"),
+				attribute.String("garden.app.name", tt.wantName),
+				attribute.String("garden.app.version", tt.wantVersion),
+				attribute.String("garden.stack", "blue"),
+				attribute.String("team", "payments"),
+			}, attrs)
+		})
+	}
+}
+
+func BenchmarkOtelMeter_DefaultHistogram(b *testing.B) {
+	meter := benchmarkMeter()
+	ctx := context.Background()
+	fields := util.ExtraFields{"route": "/orders"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := meter.DefaultHistogram(ctx, "request.duration", 12.5, fields); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkOtelMeter_DefaultCounter(b *testing.B) {
+	meter := benchmarkMeter()
+	ctx := context.Background()
+	fields := util.ExtraFields{"route": "/orders"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := meter.DefaultCounter(ctx, "request.count", 1, fields); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkOtelMeter_DefaultCounter_CardinalityLimit measures the limiter on sets it already admitted, the common case.
+func BenchmarkOtelMeter_DefaultCounter_CardinalityLimit(b *testing.B) {
+	meter := newTestMeter(metric.NewNoopMeter(), config.Config{
+		Service:                config.Service{Name: "bench-service", Version: "1.0.0"},
+		DefaultFields:          &map[string]string{"team": "payments"},
+		MetricCardinalityLimit: 100,
+	})
+	ctx := context.Background()
+	fields := util.ExtraFields{"route": "/orders", "method": "GET"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := meter.DefaultCounter(ctx, "request.count", 1, fields); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkOtelMeter_DefaultCounter_Parallel(b *testing.B) {
+	meter := benchmarkMeter()
+	fields := util.ExtraFields{"route": "/orders"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		ctx := context.Background()
+		for pb.Next() {
+			if err := meter.DefaultCounter(ctx, "request.count", 1, fields); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+type measurement struct {
+	kind  string
+	name  string
+	value float64
+	attrs []attribute.KeyValue
+}
+
+type observation struct {
+	value float64
+	attrs map[string]string
+}
+
+// fakeMeter stands in for the SDK meter. It counts the instruments created through it, keeps every measurement of its
+// synchronous instruments, and keeps the registered callbacks to run them on collect, like the SDK does on every
+// collection.
+type fakeMeter struct {
+	metric.Meter
+	created uint64
+
+	mu           sync.Mutex
+	measurements []measurement
+	callbacks    []func(context.Context)
+	observations map[string][]observation
+}
+
+func newFakeMeter() *fakeMeter {
+	return &fakeMeter{Meter: metric.NewNoopMeter()}
+}
+
+func (meter *fakeMeter) SyncInt64() syncint64.InstrumentProvider {
+	return fakeInt64Provider{meter: meter}
+}
+
+func (meter *fakeMeter) SyncFloat64() syncfloat64.InstrumentProvider {
+	return fakeFloat64Provider{meter: meter}
+}
+
+func (meter *fakeMeter) AsyncInt64() asyncint64.InstrumentProvider {
+	return fakeAsyncInt64Provider{InstrumentProvider: meter.Meter.AsyncInt64(), meter: meter}
+}
+
+func (meter *fakeMeter) AsyncFloat64() asyncfloat64.InstrumentProvider {
+	return fakeAsyncFloat64Provider{InstrumentProvider: meter.Meter.AsyncFloat64(), meter: meter}
+}
+
+func (meter *fakeMeter) RegisterCallback(_ []instrument.Asynchronous, function func(context.Context)) error {
+	meter.mu.Lock()
+	defer meter.mu.Unlock()
+	meter.callbacks = append(meter.callbacks, function)
+	return nil
+}
+
+func (meter *fakeMeter) create() {
+	atomic.AddUint64(&meter.created, 1)
+}
+
+func (meter *fakeMeter) record(kind, name string, value float64, attrs []attribute.KeyValue) {
+	meter.mu.Lock()
+	defer meter.mu.Unlock()
+	meter.measurements = append(meter.measurements, measurement{kind: kind, name: name, value: value, attrs: attrs})
+}
+
+// collect runs every callback and returns the observations of that collection, keyed by instrument name.
+func (meter *fakeMeter) collect() map[string][]observation {
+	meter.mu.Lock()
+	callbacks := append([]func(context.Context){}, meter.callbacks...)
+	meter.observations = map[string][]observation{}
+	meter.mu.Unlock()
+
+	for _, callback := range callbacks {
+		callback(context.Background())
+	}
+
+	meter.mu.Lock()
+	defer meter.mu.Unlock()
+	return meter.observations
+}
+
+func (meter *fakeMeter) observe(name string, value float64, attrs []attribute.KeyValue) {
+	observed := observation{value: value, attrs: map[string]string{}}
+	for _, attr := range attrs {
+		observed.attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+
+	meter.mu.Lock()
+	defer meter.mu.Unlock()
+	meter.observations[name] = append(meter.observations[name], observed)
+}
+
+// fakeInstrument stands in for every synchronous instrument, which either Add or Record values
+type fakeInstrument[N int64 | float64] struct {
+	instrument.Synchronous
+	kind  string
+	name  string
+	meter *fakeMeter
+}
+
+func newFakeInstrument[N int64 | float64](meter *fakeMeter, kind, name string) fakeInstrument[N] {
+	meter.create()
+	return fakeInstrument[N]{kind: kind, name: name, meter: meter}
+}
+
+func (fake fakeInstrument[N]) Add(_ context.Context, value N, attrs ...attribute.KeyValue) {
+	fake.meter.record(fake.kind, fake.name, float64(value), attrs)
+}
+
+func (fake fakeInstrument[N]) Record(_ context.Context, value N, attrs ...attribute.KeyValue) {
+	fake.meter.record(fake.kind, fake.name, float64(value), attrs)
+}
+
+// fakeObserver stands in for both asynchronous gauges and counters, which share the same methods. It wraps the
+// instrument it replaces.
+type fakeObserver[N int64 | float64] struct {
+	instrument.Asynchronous
+	name  string
+	meter *fakeMeter
+}
+
+func newFakeObserver[N int64 | float64](meter *fakeMeter, name string, replaced instrument.Asynchronous) fakeObserver[N] {
+	meter.create()
+	return fakeObserver[N]{Asynchronous: replaced, name: name, meter: meter}
+}
+
+func (fake fakeObserver[N]) Observe(_ context.Context, value N, attrs ...attribute.KeyValue) {
+	fake.meter.observe(fake.name, float64(value), attrs)
+}
+
+type fakeInt64Provider struct {
+	meter *fakeMeter
+}
+
+func (provider fakeInt64Provider) Counter(name string, _ ...instrument.Option) (syncint64.Counter, error) {
+	return newFakeInstrument[int64](provider.meter, "int64 counter", name), nil
+}
+
+func (provider fakeInt64Provider) UpDownCounter(name string, _ ...instrument.Option) (syncint64.UpDownCounter, error) {
+	return newFakeInstrument[int64](provider.meter, "int64 up-down counter", name), nil
+}
+
+func (provider fakeInt64Provider) Histogram(name string, _ ...instrument.Option) (syncint64.Histogram, error) {
+	return newFakeInstrument[int64](provider.meter, "int64 histogram", name), nil
+}
+
+type fakeFloat64Provider struct {
+	meter *fakeMeter
+}
+
+func (provider fakeFloat64Provider) Counter(name string, _ ...instrument.Option) (syncfloat64.Counter, error) {
+	return newFakeInstrument[float64](provider.meter, "float64 counter", name), nil
+}
+
+func (provider fakeFloat64Provider) UpDownCounter(name string, _ ...instrument.Option) (syncfloat64.UpDownCounter, error) {
+	return newFakeInstrument[float64](provider.meter, "float64 up-down counter", name), nil
+}
+
+func (provider fakeFloat64Provider) Histogram(name string, _ ...instrument.Option) (syncfloat64.Histogram, error) {
+	return newFakeInstrument[float64](provider.meter, "float64 histogram", name), nil
+}
+
+type fakeAsyncInt64Provider struct {
+	asyncint64.InstrumentProvider
+	meter *fakeMeter
+}
+
+func (provider fakeAsyncInt64Provider) Gauge(name string, opts ...instrument.Option) (asyncint64.Gauge, error) {
+	gauge, err := provider.InstrumentProvider.Gauge(name, opts...)
+	return newFakeObserver[int64](provider.meter, name, gauge), err
+}
+
+func (provider fakeAsyncInt64Provider) Counter(name string, opts ...instrument.Option) (asyncint64.Counter, error) {
+	counter, err := provider.InstrumentProvider.Counter(name, opts...)
+	return newFakeObserver[int64](provider.meter, name, counter), err
+}
+
+type fakeAsyncFloat64Provider struct {
+	asyncfloat64.InstrumentProvider
+	meter *fakeMeter
+}
+
+func (provider fakeAsyncFloat64Provider) Gauge(name string, opts ...instrument.Option) (asyncfloat64.Gauge, error) {
+	gauge, err := provider.InstrumentProvider.Gauge(name, opts...)
+	return newFakeObserver[float64](provider.meter, name, gauge), err
+}
+
+func (provider fakeAsyncFloat64Provider) Counter(name string, opts ...instrument.Option) (asyncfloat64.Counter, error) {
+	counter, err := provider.InstrumentProvider.Counter(name, opts...)
+	return newFakeObserver[float64](provider.meter, name, counter), err
+}
+
+func TestOtelMeter_InstrumentKinds(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		record func(meter *OtelMeter) error
+		want   measurement
+	}{
+		{
+			name: "float64 histogram",
+			record: func(meter *OtelMeter) error {
+				return meter.DefaultHistogram(ctx, "request.duration", 12.5, nil)
+			},
+			want: measurement{kind: "float64 histogram", name: "request.duration", value: 12.5},
+		},
+		{
+			name: "int64 histogram",
+			record: func(meter *OtelMeter) error {
+				return meter.DefaultInt64Histogram(ctx, "response.size", 512, nil)
+			},
+			want: measurement{kind: "int64 histogram", name: "response.size", value: 512},
+		},
+		{
+			name: "int64 counter",
+			record: func(meter *OtelMeter) error {
+				return meter.DefaultCounter(ctx, "orders.created", 1, nil)
+			},
+			want: measurement{kind: "int64 counter", name: "orders.created", value: 1},
+		},
+		{
+			name: "float64 counter",
+			record: func(meter *OtelMeter) error {
+				return meter.DefaultFloat64Counter(ctx, "billing.cost", 0.25, nil)
+			},
+			want: measurement{kind: "float64 counter", name: "billing.cost", value: 0.25},
+		},
+		{
+			name: "int64 up-down counter",
+			record: func(meter *OtelMeter) error {
+				return meter.DefaultUpDownCounter(ctx, "requests.in_flight", -1, nil)
+			},
+			want: measurement{kind: "int64 up-down counter", name: "requests.in_flight", value: -1},
+		},
+		{
+			name: "float64 up-down counter",
+			record: func(meter *OtelMeter) error {
+				return meter.DefaultFloat64UpDownCounter(ctx, "queue.backlog_kb", -1.5, nil)
+			},
+			want: measurement{kind: "float64 up-down counter", name: "queue.backlog_kb", value: -1.5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recording := newFakeMeter()
+			meter := newTestMeter(recording, config.Config{})
+
+			assert.NoError(t, tt.record(meter))
+			if assert.Len(t, recording.measurements, 1) {
+				got := recording.measurements[0]
+				assert.Equal(t, tt.want, measurement{kind: got.kind, name: got.name, value: got.value})
+			}
+		})
+	}
+}
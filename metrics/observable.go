@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Unregister stops sampling a function registered with RegisterGaugeFunc or RegisterCounterFunc. Calling it more than
+// once is a no-op.
+type Unregister func()
+
+type numeric interface {
+	int64 | float64
+}
+
+// observer is implemented by the asynchronous gauge and counter instruments.
+type observer[N numeric] interface {
+	Observe(ctx context.Context, x N, attrs ...attribute.KeyValue)
+}
+
+// observableInstrument reports everything known about an asynchronous instrument from a single callback, registered
+// once when the instrument is created: the latest pushed value of every series and the current value of every
+// registered function.
+type observableInstrument[N numeric] struct {
+	observer observer[N]
+
+	mu     sync.Mutex
+	series map[attribute.Distinct]observedSeries[N]
+	funcs  map[uint64]observedFunc[N]
+	nextID uint64
+}
+
+type observedSeries[N numeric] struct {
+	attrs attribute.Set
+	value N
+}
+
+type observedFunc[N numeric] struct {
+	attrs    []attribute.KeyValue
+	function func(ctx context.Context) N
+}
+
+func newObservableInstrument[N numeric](observer observer[N]) *observableInstrument[N] {
+	return &observableInstrument[N]{
+		observer: observer,
+		series:   map[attribute.Distinct]observedSeries[N]{},
+		funcs:    map[uint64]observedFunc[N]{},
+	}
+}
+
+// set replaces the value of the series identified by attrs.
+func (observable *observableInstrument[N]) set(value N, attrs []attribute.KeyValue) {
+	set := attribute.NewSet(attrs...)
+
+	observable.mu.Lock()
+	defer observable.mu.Unlock()
+	observable.series[set.Equivalent()] = observedSeries[N]{attrs: set, value: value}
+}
+
+// remove stops reporting the series identified by attrs.
+func (observable *observableInstrument[N]) remove(attrs []attribute.KeyValue) {
+	set := attribute.NewSet(attrs...)
+
+	observable.mu.Lock()
+	defer observable.mu.Unlock()
+	delete(observable.series, set.Equivalent())
+}
+
+// register samples function with attrs on every collection until the returned Unregister is called.
+func (observable *observableInstrument[N]) register(function func(ctx context.Context) N, attrs []attribute.KeyValue) Unregister {
+	observable.mu.Lock()
+	defer observable.mu.Unlock()
+
+	id := observable.nextID
+	observable.nextID++
+	observable.funcs[id] = observedFunc[N]{attrs: attrs, function: function}
+
+	return func() {
+		observable.mu.Lock()
+		defer observable.mu.Unlock()
+		delete(observable.funcs, id)
+	}
+}
+
+func (observable *observableInstrument[N]) observe(ctx context.Context) {
+	observable.mu.Lock()
+	for _, series := range observable.series {
+		observable.observer.Observe(ctx, series.value, series.attrs.ToSlice()...)
+	}
+	funcs := make([]observedFunc[N], 0, len(observable.funcs))
+	for _, registered := range observable.funcs {
+		funcs = append(funcs, registered)
+	}
+	observable.mu.Unlock()
+
+	// Functions run without the lock, so they may register or unregister others
+	for _, registered := range funcs {
+		observable.observer.Observe(ctx, registered.function(ctx), registered.attrs...)
+	}
+}
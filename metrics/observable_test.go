@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/garden/observability-commons/config"
+	"github.com/garden/observability-commons/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOtelMeter_RegisterGaugeFunc(t *testing.T) {
+	collecting := newFakeMeter()
+	meter := newTestMeter(collecting, config.Config{})
+
+	var queueLength int64 = 3
+	unregister, err := meter.RegisterGaugeFunc("queue.length", func(context.Context) int64 {
+		return atomic.LoadInt64(&queueLength)
+	}, util.ExtraFields{"queue": "emails"})
+	assert.NoError(t, err)
+
+	observations := collecting.collect()["queue.length"]
+	if assert.Len(t, observations, 1) {
+		assert.Equal(t, float64(3), observations[0].value)
+		assert.Equal(t, "emails", observations[0].attrs["queue"])
+	}
+
+	atomic.StoreInt64(&queueLength, 8)
+	observations = collecting.collect()["queue.length"]
+	if assert.Len(t, observations, 1) {
+		assert.Equal(t, float64(8), observations[0].value, "the function must be sampled on every collection")
+	}
+
+	unregister()
+	unregister()
+	assert.Empty(t, collecting.collect()["queue.length"])
+}
+
+func TestOtelMeter_RegisterCounterFunc(t *testing.T) {
+	collecting := newFakeMeter()
+	meter := newTestMeter(collecting, config.Config{})
+
+	var hits int64
+	unregisterHits, err := meter.RegisterCounterFunc("cache.requests", func(context.Context) int64 {
+		return atomic.AddInt64(&hits, 10)
+	}, util.ExtraFields{"result": "hit"})
+	assert.NoError(t, err)
+	_, err = meter.RegisterCounterFunc("cache.requests", func(context.Context) int64 {
+		return 4
+	}, util.ExtraFields{"result": "miss"})
+	assert.NoError(t, err)
+
+	assert.Len(t, collecting.callbacks, 1, "functions of the same counter must share its callback")
+
+	values := map[string]float64{}
+	for _, observed := range collecting.collect()["cache.requests"] {
+		values[observed.attrs["result"]] = observed.value
+	}
+	assert.Equal(t, map[string]float64{"hit": 10, "miss": 4}, values)
+
+	unregisterHits()
+	observations := collecting.collect()["cache.requests"]
+	if assert.Len(t, observations, 1) {
+		assert.Equal(t, "miss", observations[0].attrs["result"])
+	}
+}
+
+func TestOtelMeter_RegisterGaugeFunc_WithPushedSeries(t *testing.T) {
+	collecting := newFakeMeter()
+	meter := newTestMeter(collecting, config.Config{})
+	ctx := context.Background()
+
+	assert.NoError(t, meter.DefaultGauge(ctx, "pool.size", 5, util.ExtraFields{"pool": "primary"}))
+	_, err := meter.RegisterGaugeFunc("pool.size", func(context.Context) int64 {
+		return 2
+	}, util.ExtraFields{"pool": "replica"})
+	assert.NoError(t, err)
+
+	values := map[string]float64{}
+	for _, observed := range collecting.collect()["pool.size"] {
+		values[observed.attrs["pool"]] = observed.value
+	}
+	assert.Equal(t, map[string]float64{"primary": 5, "replica": 2}, values)
+	assert.Len(t, collecting.callbacks, 1)
+}
@@ -0,0 +1,459 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/unit"
+)
+
+const (
+	// clockTicksPerSecond is USER_HZ, the unit of the CPU times of /proc/self/stat, which is 100 on every Linux platform
+	clockTicksPerSecond = 100
+	// cgroupV1Unlimited is the smallest memory limit cgroup v1 reports when none is set, PAGE_COUNTER_MAX pages
+	cgroupV1Unlimited = 1 << 62
+)
+
+var (
+	cpuModeKey          = attribute.Key("cpu.mode")
+	networkTransportKey = attribute.Key("network.transport")
+	networkTypeKey      = attribute.Key("network.type")
+
+	// socketTables are the socket tables of /proc/self/net, matched against the process's socket descriptors
+	socketTables = []socketType{
+		{table: "tcp", transport: "tcp", network: "ipv4"},
+		{table: "tcp6", transport: "tcp", network: "ipv6"},
+		{table: "udp", transport: "udp", network: "ipv4"},
+		{table: "udp6", transport: "udp", network: "ipv6"},
+	}
+)
+
+type socketType struct {
+	table     string
+	transport string
+	network   string
+}
+
+// processStats is one reading of /proc/self and the process's cgroup. Values that couldn't be read are -1.
+type processStats struct {
+	userTime, systemTime float64
+	residentMemory       int64
+	virtualMemory        int64
+	threads              int64
+	openFiles            int64
+	openFilesLimit       int64
+	// sockets counts the process's TCP and UDP sockets by type, nil when unreadable
+	sockets map[socketType]int64
+
+	cgroupMemoryUsage int64
+	cgroupMemoryLimit int64
+	cgroupCPULimit    float64
+}
+
+// cgroupFiles are the files of the process's cgroup, resolved once since processes don't change cgroup. Empty paths
+// are not available.
+type cgroupFiles struct {
+	memoryUsage string
+	memoryLimit string
+	// cpuMax holds both the quota and the period on cgroup v2, cpuQuota and cpuPeriod hold them on cgroup v1
+	cpuMax    string
+	cpuQuota  string
+	cpuPeriod string
+}
+
+// processCollector publishes the process's CPU time, memory, threads, file descriptors and sockets read from /proc/self,
+// and the memory and CPU limits of its cgroup, on Linux. Sources that can't be read when it starts are skipped.
+type processCollector struct {
+	root   string
+	cgroup cgroupFiles
+
+	instruments       *asyncInstruments
+	cpuTime           collectedInstrument[float64]
+	memoryUsage       collectedInstrument[int64]
+	memoryVirtual     collectedInstrument[int64]
+	threads           collectedInstrument[int64]
+	openFiles         collectedInstrument[int64]
+	openFilesLimit    collectedInstrument[int64]
+	sockets           collectedInstrument[int64]
+	cgroupMemoryUsage collectedInstrument[int64]
+	cgroupMemoryLimit collectedInstrument[int64]
+	cgroupCPULimit    collectedInstrument[float64]
+}
+
+// StartProcessCollector publishes the process and cgroup metrics, e.g. process.cpu.time and container.memory.limit,
+// with the default attributes. It must be called once per meter; outside Linux it publishes nothing.
+func (meter OtelMeter) StartProcessCollector() error {
+	collector, err := newProcessCollector(newAsyncInstruments(meter.meter, meter.views, meter.defaultAttrs), "/")
+	if err != nil {
+		return fmt.Errorf("error creating process collector: %w", err)
+	}
+	if err = collector.instruments.register(collector.observe); err != nil {
+		return fmt.Errorf("error creating process collector: %w", err)
+	}
+	return nil
+}
+
+// newProcessCollector reads /proc and /sys under root, which is "/" outside tests.
+func newProcessCollector(instruments *asyncInstruments, root string) (*processCollector, error) {
+	collector := &processCollector{
+		root:        root,
+		cgroup:      resolveCgroupFiles(root),
+		instruments: instruments,
+	}
+	stats := collector.read()
+
+	var err error
+	create := func(created *collectedInstrument[int64], name string, instrumentUnit unit.Unit, kind asynchronousFunc[int64], available bool) {
+		if err == nil && available {
+			*created, err = newCollectedInstrument(instruments, name, instrumentUnit, kind)
+		}
+	}
+	create(&collector.memoryUsage, "process.memory.usage", unit.Bytes, int64Gauge, stats.residentMemory >= 0)
+	create(&collector.memoryVirtual, "process.memory.virtual", unit.Bytes, int64Gauge, stats.virtualMemory >= 0)
+	create(&collector.threads, "process.thread.count", "{thread}", int64Gauge, stats.threads >= 0)
+	create(&collector.openFiles, "process.open_file_descriptor.count", "{file_descriptor}", int64Gauge, stats.openFiles >= 0)
+	create(&collector.openFilesLimit, "process.open_file_descriptor.limit", "{file_descriptor}", int64Gauge, stats.openFilesLimit >= 0)
+	create(&collector.sockets, "process.network.socket.count", "{socket}", int64Gauge, stats.sockets != nil)
+	create(&collector.cgroupMemoryUsage, "container.memory.usage", unit.Bytes, int64Gauge, stats.cgroupMemoryUsage >= 0)
+	create(&collector.cgroupMemoryLimit, "container.memory.limit", unit.Bytes, int64Gauge, stats.cgroupMemoryLimit >= 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.userTime >= 0 {
+		if collector.cpuTime, err = newCollectedInstrument(instruments, "process.cpu.time", "s", float64Counter); err != nil {
+			return nil, err
+		}
+	}
+	if stats.cgroupCPULimit >= 0 {
+		if collector.cgroupCPULimit, err = newCollectedInstrument(instruments, "container.cpu.limit", "{cpu}", float64Gauge); err != nil {
+			return nil, err
+		}
+	}
+
+	return collector, nil
+}
+
+func (collector *processCollector) observe(ctx context.Context) {
+	stats := collector.read()
+
+	if stats.userTime >= 0 {
+		collector.cpuTime.observe(ctx, stats.userTime, cpuModeKey.String("user"))
+		collector.cpuTime.observe(ctx, stats.systemTime, cpuModeKey.String("system"))
+	}
+	observeAvailable(ctx, collector.memoryUsage, stats.residentMemory)
+	observeAvailable(ctx, collector.memoryVirtual, stats.virtualMemory)
+	observeAvailable(ctx, collector.threads, stats.threads)
+	observeAvailable(ctx, collector.openFiles, stats.openFiles)
+	observeAvailable(ctx, collector.openFilesLimit, stats.openFilesLimit)
+	if stats.sockets != nil {
+		for _, socket := range socketTables {
+			collector.sockets.observe(ctx, stats.sockets[socket],
+				networkTransportKey.String(socket.transport), networkTypeKey.String(socket.network))
+		}
+	}
+	observeAvailable(ctx, collector.cgroupMemoryUsage, stats.cgroupMemoryUsage)
+	observeAvailable(ctx, collector.cgroupMemoryLimit, stats.cgroupMemoryLimit)
+	observeAvailable(ctx, collector.cgroupCPULimit, stats.cgroupCPULimit)
+}
+
+// observeAvailable skips the values that couldn't be read during this collection.
+func observeAvailable[N numeric](ctx context.Context, collected collectedInstrument[N], value N) {
+	if value >= 0 {
+		collected.observe(ctx, value)
+	}
+}
+
+func (collector *processCollector) read() processStats {
+	stats := processStats{
+		userTime: -1, systemTime: -1, residentMemory: -1, virtualMemory: -1, threads: -1, openFiles: -1,
+		openFilesLimit: -1, cgroupMemoryUsage: -1, cgroupMemoryLimit: -1, cgroupCPULimit: -1,
+	}
+
+	if userTime, systemTime, threads, err := collector.readStat(); err == nil {
+		stats.userTime, stats.systemTime, stats.threads = userTime, systemTime, threads
+	}
+	if residentMemory, virtualMemory, err := collector.readStatus(); err == nil {
+		stats.residentMemory, stats.virtualMemory = residentMemory, virtualMemory
+	}
+	if descriptors, err := os.ReadDir(collector.path("proc/self/fd")); err == nil {
+		stats.openFiles = int64(len(descriptors))
+		if sockets, err := collector.countSockets(descriptors); err == nil {
+			stats.sockets = sockets
+		}
+	}
+	if limit, err := collector.readOpenFilesLimit(); err == nil {
+		stats.openFilesLimit = limit
+	}
+
+	if usage, err := readCgroupValue(collector.cgroup.memoryUsage); err == nil {
+		stats.cgroupMemoryUsage = usage
+	}
+	if limit, err := readCgroupValue(collector.cgroup.memoryLimit); err == nil && limit < cgroupV1Unlimited {
+		stats.cgroupMemoryLimit = limit
+	}
+	if limit, err := collector.readCPULimit(); err == nil {
+		stats.cgroupCPULimit = limit
+	}
+	return stats
+}
+
+func (collector *processCollector) path(name string) string {
+	return filepath.Join(collector.root, name)
+}
+
+// readStat reads the CPU times and thread count of /proc/self/stat, whose fields follow the command name in
+// parentheses, which may itself contain spaces.
+func (collector *processCollector) readStat() (userTime, systemTime float64, threads int64, err error) {
+	content, err := os.ReadFile(collector.path("proc/self/stat"))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	end := bytes.LastIndexByte(content, ')')
+	if end < 0 {
+		return 0, 0, 0, errors.New("malformed /proc/self/stat")
+	}
+	// fields starts at the state, the third field, so utime, stime and num_threads are the 14th, 15th and 20th
+	fields := strings.Fields(string(content[end+1:]))
+	if len(fields) < 18 {
+		return 0, 0, 0, errors.New("malformed /proc/self/stat")
+	}
+
+	userTicks, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	systemTicks, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	threads, err = strconv.ParseInt(fields[17], 10, 64)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return float64(userTicks) / clockTicksPerSecond, float64(systemTicks) / clockTicksPerSecond, threads, nil
+}
+
+// readStatus reads the resident and virtual memory of /proc/self/status, which reports them in kB.
+func (collector *processCollector) readStatus() (residentMemory, virtualMemory int64, err error) {
+	file, err := os.Open(collector.path("proc/self/status"))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	residentMemory, virtualMemory = -1, -1
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found || (key != "VmRSS" && key != "VmSize") {
+			continue
+		}
+
+		kilobytes, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimSpace(value), " kB"), 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		if key == "VmRSS" {
+			residentMemory = kilobytes * 1024
+		} else {
+			virtualMemory = kilobytes * 1024
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+	if residentMemory < 0 || virtualMemory < 0 {
+		return 0, 0, errors.New("no memory in /proc/self/status")
+	}
+	return residentMemory, virtualMemory, nil
+}
+
+// readOpenFilesLimit reads the soft limit of open files of /proc/self/limits. Unlimited processes report an error.
+func (collector *processCollector) readOpenFilesLimit() (int64, error) {
+	file, err := os.Open(collector.path("proc/self/limits"))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "Max open files") {
+			fields := strings.Fields(strings.TrimPrefix(line, "Max open files"))
+			if len(fields) == 0 {
+				break
+			}
+			return strconv.ParseInt(fields[0], 10, 64)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, errors.New("no open files limit in /proc/self/limits")
+}
+
+// countSockets matches the socket descriptors of the process, linking to "socket:[inode]", with the inodes of the
+// socket tables. Other sockets, e.g. Unix ones, are not counted.
+func (collector *processCollector) countSockets(descriptors []os.DirEntry) (map[socketType]int64, error) {
+	types := map[string]socketType{}
+	for _, socket := range socketTables {
+		if err := collector.readSocketTable(socket, types); err != nil {
+			return nil, err
+		}
+	}
+
+	sockets := make(map[socketType]int64, len(socketTables))
+	for _, descriptor := range descriptors {
+		target, err := os.Readlink(collector.path(filepath.Join("proc/self/fd", descriptor.Name())))
+		if err != nil {
+			// The descriptor was closed since listing them
+			continue
+		}
+		if strings.HasPrefix(target, "socket:[") {
+			if socket, ok := types[strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]")]; ok {
+				sockets[socket]++
+			}
+		}
+	}
+	return sockets, nil
+}
+
+// readSocketTable adds the inodes of a /proc/self/net table, its 10th column, to types.
+func (collector *processCollector) readSocketTable(socket socketType, types map[string]socketType) error {
+	file, err := os.Open(collector.path(filepath.Join("proc/self/net", socket.table)))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	// The first line holds the column names
+	scanner.Scan()
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) >= 10 {
+			types[fields[9]] = socket
+		}
+	}
+	return scanner.Err()
+}
+
+// readCPULimit returns the CPUs the cgroup may use, from its quota and period. Unlimited cgroups report an error.
+func (collector *processCollector) readCPULimit() (float64, error) {
+	var quota, period string
+	if collector.cgroup.cpuMax != "" {
+		content, err := os.ReadFile(collector.cgroup.cpuMax)
+		if err != nil {
+			return 0, err
+		}
+		fields := strings.Fields(string(content))
+		if len(fields) != 2 {
+			return 0, errors.New("malformed cpu.max")
+		}
+		quota, period = fields[0], fields[1]
+	} else {
+		if collector.cgroup.cpuQuota == "" {
+			return 0, errors.New("no cgroup cpu controller")
+		}
+		content, err := os.ReadFile(collector.cgroup.cpuQuota)
+		if err != nil {
+			return 0, err
+		}
+		quota = strings.TrimSpace(string(content))
+		if content, err = os.ReadFile(collector.cgroup.cpuPeriod); err != nil {
+			return 0, err
+		}
+		period = strings.TrimSpace(string(content))
+	}
+
+	if quota == "max" || quota == "-1" {
+		return 0, errors.New("no cgroup cpu limit")
+	}
+	quotaValue, err := strconv.ParseFloat(quota, 64)
+	if err != nil {
+		return 0, err
+	}
+	periodValue, err := strconv.ParseFloat(period, 64)
+	if err != nil || periodValue <= 0 {
+		return 0, fmt.Errorf("malformed cgroup cpu period %q", period)
+	}
+	return quotaValue / periodValue, nil
+}
+
+// readCgroupValue reads a cgroup file holding a single number. "max", cgroup v2's unlimited value, reports an error.
+func readCgroupValue(path string) (int64, error) {
+	if path == "" {
+		return 0, errors.New("no cgroup memory controller")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+}
+
+// resolveCgroupFiles finds the cgroup of the process from /proc/self/cgroup, on cgroup v2 when
+// /sys/fs/cgroup/cgroup.controllers exists and v1 otherwise. Inside containers the cgroup namespace usually hides the
+// path, so the files are looked up at the root of the controller when the path doesn't exist.
+func resolveCgroupFiles(root string) cgroupFiles {
+	content, err := os.ReadFile(filepath.Join(root, "proc/self/cgroup"))
+	if err != nil {
+		return cgroupFiles{}
+	}
+
+	// Each line is "hierarchy-ID:controllers:path", controllers being empty on cgroup v2
+	paths := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			paths[controller] = parts[2]
+		}
+	}
+
+	mount := filepath.Join(root, "sys/fs/cgroup")
+	if _, err := os.Stat(filepath.Join(mount, "cgroup.controllers")); err == nil {
+		path, ok := paths[""]
+		if !ok {
+			return cgroupFiles{}
+		}
+		dir := cgroupDir(mount, path)
+		return cgroupFiles{
+			memoryUsage: filepath.Join(dir, "memory.current"),
+			memoryLimit: filepath.Join(dir, "memory.max"),
+			cpuMax:      filepath.Join(dir, "cpu.max"),
+		}
+	}
+
+	var files cgroupFiles
+	if path, ok := paths["memory"]; ok {
+		dir := cgroupDir(filepath.Join(mount, "memory"), path)
+		files.memoryUsage = filepath.Join(dir, "memory.usage_in_bytes")
+		files.memoryLimit = filepath.Join(dir, "memory.limit_in_bytes")
+	}
+	if path, ok := paths["cpu"]; ok {
+		dir := cgroupDir(filepath.Join(mount, "cpu"), path)
+		files.cpuQuota = filepath.Join(dir, "cpu.cfs_quota_us")
+		files.cpuPeriod = filepath.Join(dir, "cpu.cfs_period_us")
+	}
+	return files
+}
+
+func cgroupDir(mount, path string) string {
+	dir := filepath.Join(mount, path)
+	if _, err := os.Stat(dir); err != nil {
+		return mount
+	}
+	return dir
+}
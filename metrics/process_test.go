@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/garden/observability-commons/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestProcessCollector(t *testing.T, root string) *fakeMeter {
+	collecting := newFakeMeter()
+	meter := newTestMeter(collecting, config.Config{Service: config.Service{Name: "checkout", Version: "1.0.0"}})
+
+	collector, err := newProcessCollector(newAsyncInstruments(collecting, meter.views, meter.defaultAttrs), root)
+	assert.NoError(t, err)
+	assert.NoError(t, collector.instruments.register(collector.observe))
+	return collecting
+}
+
+// valuesBy returns the observed values of a metric keyed by the given attribute.
+func valuesBy(observations []observation, key string) map[string]float64 {
+	values := map[string]float64{}
+	for _, observed := range observations {
+		values[observed.attrs[key]] = observed.value
+	}
+	return values
+}
+
+func TestProcessCollector_CgroupV2(t *testing.T) {
+	observed := newTestProcessCollector(t, "testdata/cgroupv2").collect()
+
+	assert.Equal(t, map[string]float64{"user": 2.5, "system": 0.75}, valuesBy(observed["process.cpu.time"], "cpu.mode"))
+	assert.Equal(t, map[string]float64{"checkout": 20480 * 1024}, valuesBy(observed["process.memory.usage"], "garden.app.name"))
+	assert.Equal(t, float64(695648*1024), observed["process.memory.virtual"][0].value)
+	assert.Equal(t, float64(12), observed["process.thread.count"][0].value)
+	assert.Equal(t, float64(9), observed["process.open_file_descriptor.count"][0].value)
+	assert.Equal(t, float64(1024), observed["process.open_file_descriptor.limit"][0].value)
+
+	sockets := map[string]float64{}
+	for _, observation := range observed["process.network.socket.count"] {
+		sockets[observation.attrs["network.transport"]+"/"+observation.attrs["network.type"]] = observation.value
+	}
+	assert.Equal(t, map[string]float64{"tcp/ipv4": 2, "tcp/ipv6": 1, "udp/ipv4": 1, "udp/ipv6": 0}, sockets)
+
+	assert.Equal(t, float64(104857600), observed["container.memory.usage"][0].value)
+	assert.Equal(t, float64(536870912), observed["container.memory.limit"][0].value)
+	assert.Equal(t, 1.5, observed["container.cpu.limit"][0].value)
+}
+
+func TestProcessCollector_CgroupV1(t *testing.T) {
+	observed := newTestProcessCollector(t, "testdata/cgroupv1").collect()
+
+	assert.Equal(t, float64(52428800), observed["container.memory.usage"][0].value)
+	assert.NotContains(t, observed, "container.memory.limit", "unlimited cgroups have no limit")
+	assert.Equal(t, float64(2), observed["container.cpu.limit"][0].value)
+	assert.NotContains(t, observed, "process.cpu.time", "missing sources are skipped")
+	assert.NotContains(t, observed, "process.open_file_descriptor.count", "missing sources are skipped")
+}
+
+func TestProcessCollector_NoProc(t *testing.T) {
+	assert.Empty(t, newTestProcessCollector(t, t.TempDir()).collect())
+}
@@ -0,0 +1,310 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	controller "go.opentelemetry.io/otel/sdk/metric/controller/basic"
+	"go.opentelemetry.io/otel/sdk/metric/export"
+	"go.opentelemetry.io/otel/sdk/metric/export/aggregation"
+	"go.opentelemetry.io/otel/sdk/metric/number"
+	"go.opentelemetry.io/otel/sdk/metric/sdkapi"
+)
+
+const (
+	prometheusContentType  = "text/plain; version=0.0.4; charset=utf-8"
+	openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// prometheusHandler serves the meter's instruments in the Prometheus text exposition format, or in the OpenMetrics one
+// when the scraper asks for it. Without pushes, each scrape collects. Alongside pushes, the running controller collects
+// every flush interval and scrapes serve its last collection, which ForEach reads under the checkpoint's lock.
+type prometheusHandler struct {
+	ctrl    *controller.Controller
+	timeout time.Duration
+	collect bool
+}
+
+func (handler prometheusHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithTimeout(request.Context(), handler.timeout)
+	defer cancel()
+
+	if handler.collect {
+		if err := handler.ctrl.Collect(ctx); err != nil {
+			http.Error(writer, fmt.Sprintf("error collecting metrics: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	openMetrics := strings.Contains(request.Header.Get("Accept"), "application/openmetrics-text")
+	var body bytes.Buffer
+	if err := writePrometheus(&body, handler.ctrl, openMetrics); err != nil {
+		http.Error(writer, fmt.Sprintf("error encoding metrics: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if openMetrics {
+		writer.Header().Set("Content-Type", openMetricsContentType)
+	} else {
+		writer.Header().Set("Content-Type", prometheusContentType)
+	}
+	_, _ = body.WriteTo(writer)
+}
+
+type prometheusFamily struct {
+	name   string
+	kind   string
+	series []prometheusSeries
+}
+
+type prometheusSeries struct {
+	labels  string
+	samples []prometheusSample
+}
+
+type prometheusSample struct {
+	suffix string
+	le     string
+	value  string
+}
+
+// writePrometheus writes every record of reader, one family per exported name, sorted by name and labels so scrapes
+// are stable.
+func writePrometheus(writer io.Writer, reader export.InstrumentationLibraryReader, openMetrics bool) error {
+	families := map[string]*prometheusFamily{}
+	err := reader.ForEach(func(_ instrumentation.Library, libraryReader export.Reader) error {
+		return libraryReader.ForEach(aggregation.CumulativeTemporalitySelector(), func(record export.Record) error {
+			return addPrometheusRecord(families, record)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out strings.Builder
+	for _, name := range names {
+		family := families[name]
+		typeName := family.name
+		if family.kind == "counter" && !openMetrics {
+			typeName += "_total"
+		}
+		fmt.Fprintf(&out, "# TYPE %s %s\n", typeName, family.kind)
+
+		sort.Slice(family.series, func(i, j int) bool { return family.series[i].labels < family.series[j].labels })
+		for _, series := range family.series {
+			for _, sample := range series.samples {
+				out.WriteString(family.name + sample.suffix)
+				writePrometheusLabels(&out, series.labels, sample.le)
+				out.WriteString(" " + sample.value + "\n")
+			}
+		}
+	}
+	if openMetrics {
+		out.WriteString("# EOF\n")
+	}
+
+	_, err = io.WriteString(writer, out.String())
+	return err
+}
+
+func addPrometheusRecord(families map[string]*prometheusFamily, record export.Record) error {
+	descriptor := record.Descriptor()
+	name := sanitizePrometheusName(descriptor.Name(), true)
+	var kind string
+	var samples []prometheusSample
+
+	switch agg := record.Aggregation().(type) {
+	case *exponentialAggregator:
+		kind = "histogram"
+		samples = exponentialPrometheusSamples(agg.snapshot())
+	case aggregation.Histogram:
+		kind = "histogram"
+		buckets, err := agg.Histogram()
+		if err != nil {
+			return err
+		}
+		sum, err := agg.Sum()
+		if err != nil {
+			return err
+		}
+		count, err := agg.Count()
+		if err != nil {
+			return err
+		}
+
+		var cumulative uint64
+		for i, boundary := range buckets.Boundaries {
+			cumulative += buckets.Counts[i]
+			samples = append(samples, bucketSample(boundary, cumulative))
+		}
+		samples = append(samples,
+			bucketSample(math.Inf(1), count),
+			prometheusSample{suffix: "_sum", value: formatPrometheusNumber(sum, descriptor.NumberKind())},
+			prometheusSample{suffix: "_count", value: strconv.FormatUint(count, 10)},
+		)
+	case aggregation.LastValue:
+		kind = "gauge"
+		value, _, err := agg.LastValue()
+		if errors.Is(err, aggregation.ErrNoData) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		samples = []prometheusSample{{value: formatPrometheusNumber(value, descriptor.NumberKind())}}
+	case aggregation.Sum:
+		value, err := agg.Sum()
+		if err != nil {
+			return err
+		}
+
+		kind = "gauge"
+		sample := prometheusSample{value: formatPrometheusNumber(value, descriptor.NumberKind())}
+		if monotonic(descriptor.InstrumentKind()) {
+			kind = "counter"
+			name = strings.TrimSuffix(name, "_total")
+			sample.suffix = "_total"
+		}
+		samples = []prometheusSample{sample}
+	default:
+		return nil
+	}
+
+	family, ok := families[name]
+	if !ok {
+		family = &prometheusFamily{name: name, kind: kind}
+		families[name] = family
+	}
+	family.series = append(family.series, prometheusSeries{labels: prometheusLabels(record.Attributes()), samples: samples})
+	return nil
+}
+
+// exponentialPrometheusSamples turns exponential buckets into cumulative explicit ones: the negative buckets from the
+// lowest values up, the zero bucket, then the positive buckets.
+func exponentialPrometheusSamples(state exponentialState) []prometheusSample {
+	var samples []prometheusSample
+	var cumulative uint64
+	for i := len(state.negative.counts) - 1; i >= 0; i-- {
+		cumulative += state.negative.counts[i]
+		samples = append(samples, bucketSample(-exponentialBoundary(state.negative.offset+int32(i), state.scale), cumulative))
+	}
+	if len(state.negative.counts) > 0 || state.zeroCount > 0 {
+		cumulative += state.zeroCount
+		samples = append(samples, bucketSample(0, cumulative))
+	}
+	for i, count := range state.positive.counts {
+		cumulative += count
+		samples = append(samples, bucketSample(exponentialBoundary(state.positive.offset+int32(i)+1, state.scale), cumulative))
+	}
+
+	return append(samples,
+		bucketSample(math.Inf(1), state.count),
+		prometheusSample{suffix: "_sum", value: formatPrometheusFloat(state.sum)},
+		prometheusSample{suffix: "_count", value: strconv.FormatUint(state.count, 10)},
+	)
+}
+
+// exponentialBoundary returns the lower boundary of the bucket of the given index.
+func exponentialBoundary(index, scale int32) float64 {
+	return math.Exp2(math.Ldexp(float64(index), -int(scale)))
+}
+
+func bucketSample(le float64, count uint64) prometheusSample {
+	return prometheusSample{suffix: "_bucket", le: formatPrometheusFloat(le), value: strconv.FormatUint(count, 10)}
+}
+
+func monotonic(kind sdkapi.InstrumentKind) bool {
+	return kind == sdkapi.CounterInstrumentKind || kind == sdkapi.CounterObserverInstrumentKind
+}
+
+func prometheusLabels(attrs *attribute.Set) string {
+	var labels strings.Builder
+	iterator := attrs.Iter()
+	for iterator.Next() {
+		attr := iterator.Attribute()
+		if labels.Len() > 0 {
+			labels.WriteByte(',')
+		}
+		labels.WriteString(sanitizePrometheusName(string(attr.Key), false))
+		labels.WriteString(`="`)
+		labels.WriteString(escapePrometheusLabelValue(attr.Value.Emit()))
+		labels.WriteByte('"')
+	}
+	return labels.String()
+}
+
+func writePrometheusLabels(out *strings.Builder, labels, le string) {
+	if labels == "" && le == "" {
+		return
+	}
+
+	out.WriteByte('{')
+	out.WriteString(labels)
+	if le != "" {
+		if labels != "" {
+			out.WriteByte(',')
+		}
+		out.WriteString(`le="` + le + `"`)
+	}
+	out.WriteByte('}')
+}
+
+// sanitizePrometheusName replaces the characters Prometheus doesn't allow, such as the dots of "orders.processed", by
+// underscores. Colons are only allowed in metric names.
+func sanitizePrometheusName(name string, metricName bool) string {
+	sanitized := []byte(name)
+	for i, char := range sanitized {
+		valid := char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') ||
+			(char >= '0' && char <= '9') || (char == ':' && metricName)
+		if !valid {
+			sanitized[i] = '_'
+		}
+	}
+	if len(sanitized) > 0 && sanitized[0] >= '0' && sanitized[0] <= '9' {
+		return "_" + string(sanitized)
+	}
+	return string(sanitized)
+}
+
+var prometheusLabelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapePrometheusLabelValue(value string) string {
+	return prometheusLabelValueEscaper.Replace(value)
+}
+
+func formatPrometheusNumber(value number.Number, kind number.Kind) string {
+	if kind == number.Int64Kind {
+		return strconv.FormatInt(value.AsInt64(), 10)
+	}
+	return formatPrometheusFloat(value.AsFloat64())
+}
+
+func formatPrometheusFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
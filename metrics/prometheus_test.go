@@ -0,0 +1,239 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/garden/observability-commons/config"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric/export"
+	"go.opentelemetry.io/otel/sdk/metric/export/aggregation"
+	"go.opentelemetry.io/otel/sdk/metric/number"
+	"go.opentelemetry.io/otel/sdk/metric/sdkapi"
+)
+
+type staticSum number.Number
+
+func (staticSum) Kind() aggregation.Kind          { return aggregation.SumKind }
+func (sum staticSum) Sum() (number.Number, error) { return number.Number(sum), nil }
+
+type staticLastValue number.Number
+
+func (staticLastValue) Kind() aggregation.Kind { return aggregation.LastValueKind }
+func (value staticLastValue) LastValue() (number.Number, time.Time, error) {
+	return number.Number(value), time.Time{}, nil
+}
+
+type staticHistogram struct {
+	boundaries []float64
+	counts     []uint64
+	count      uint64
+	sum        float64
+}
+
+func (staticHistogram) Kind() aggregation.Kind           { return aggregation.HistogramKind }
+func (histogram staticHistogram) Count() (uint64, error) { return histogram.count, nil }
+func (histogram staticHistogram) Sum() (number.Number, error) {
+	return number.NewFloat64Number(histogram.sum), nil
+}
+func (histogram staticHistogram) Histogram() (aggregation.Buckets, error) {
+	return aggregation.Buckets{Boundaries: histogram.boundaries, Counts: histogram.counts}, nil
+}
+
+// staticReader reads the same records on every collection, like a processor with memory.
+type staticReader struct {
+	sync.RWMutex
+	records []export.Record
+}
+
+func (reader *staticReader) ForEach(_ aggregation.TemporalitySelector, recordFunc func(export.Record) error) error {
+	for _, record := range reader.records {
+		if err := recordFunc(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type staticLibraryReader struct {
+	reader *staticReader
+}
+
+func (libraryReader staticLibraryReader) ForEach(readerFunc func(instrumentation.Library, export.Reader) error) error {
+	return readerFunc(instrumentation.Library{Name: instrumentationName}, libraryReader.reader)
+}
+
+func newStaticRecord(name string, kind sdkapi.InstrumentKind, numberKind number.Kind, agg aggregation.Aggregation, attrs ...attribute.KeyValue) export.Record {
+	descriptor := sdkapi.NewDescriptor(name, kind, numberKind, "", "")
+	set := attribute.NewSet(attrs...)
+	return export.NewRecord(&descriptor, &set, agg, time.Time{}, time.Time{})
+}
+
+func TestWritePrometheus(t *testing.T) {
+	appName := attribute.String("garden.app.name", "checkout")
+
+	tests := []struct {
+		name        string
+		records     []export.Record
+		openMetrics bool
+		want        string
+	}{
+		{
+			name: "counter with dotted names",
+			records: []export.Record{
+				newStaticRecord("orders.processed", sdkapi.CounterInstrumentKind, number.Int64Kind,
+					staticSum(number.NewInt64Number(5)), appName, attribute.String("region", "us-east-1")),
+			},
+			want: "# TYPE orders_processed_total counter\n" +
+				`orders_processed_total{garden_app_name="checkout",region="us-east-1"} 5` + "\n",
+		},
+		{
+			name: "counter already ending in total in OpenMetrics",
+			records: []export.Record{
+				newStaticRecord("orders_processed_total", sdkapi.CounterObserverInstrumentKind, number.Int64Kind,
+					staticSum(number.NewInt64Number(7)), appName),
+			},
+			openMetrics: true,
+			want: "# TYPE orders_processed counter\n" +
+				`orders_processed_total{garden_app_name="checkout"} 7` + "\n" +
+				"# EOF\n",
+		},
+		{
+			name: "up-down counter and gauge sorted by name and labels",
+			records: []export.Record{
+				newStaticRecord("queue.depth", sdkapi.GaugeObserverInstrumentKind, number.Float64Kind,
+					staticLastValue(number.NewFloat64Number(2.5)), attribute.String("queue", "b")),
+				newStaticRecord("queue.depth", sdkapi.GaugeObserverInstrumentKind, number.Float64Kind,
+					staticLastValue(number.NewFloat64Number(1)), attribute.String("queue", "a\"1\"")),
+				newStaticRecord("requests.in_flight", sdkapi.UpDownCounterInstrumentKind, number.Int64Kind,
+					staticSum(number.NewInt64Number(-3))),
+			},
+			want: "# TYPE queue_depth gauge\n" +
+				`queue_depth{queue="a\"1\""} 1` + "\n" +
+				`queue_depth{queue="b"} 2.5` + "\n" +
+				"# TYPE requests_in_flight gauge\n" +
+				"requests_in_flight -3\n",
+		},
+		{
+			name: "histogram",
+			records: []export.Record{
+				newStaticRecord("request.duration", sdkapi.HistogramInstrumentKind, number.Float64Kind,
+					staticHistogram{boundaries: []float64{10, 100}, counts: []uint64{1, 2, 1}, count: 4, sum: 250.5}, appName),
+			},
+			want: "# TYPE request_duration histogram\n" +
+				`request_duration_bucket{garden_app_name="checkout",le="10"} 1` + "\n" +
+				`request_duration_bucket{garden_app_name="checkout",le="100"} 3` + "\n" +
+				`request_duration_bucket{garden_app_name="checkout",le="+Inf"} 4` + "\n" +
+				`request_duration_sum{garden_app_name="checkout"} 250.5` + "\n" +
+				`request_duration_count{garden_app_name="checkout"} 4` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			err := writePrometheus(&out, staticLibraryReader{&staticReader{records: tt.records}}, tt.openMetrics)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestWritePrometheus_ExponentialHistogram(t *testing.T) {
+	descriptor := sdkapi.NewDescriptor("request.duration", sdkapi.HistogramInstrumentKind, number.Float64Kind, "", "")
+	agg := &newExponentialAggregators(1, 0, 160)[0]
+	for _, value := range []float64{-3, 0, 3, 4, 7} {
+		assert.NoError(t, agg.Update(context.Background(), number.NewFloat64Number(value), &descriptor))
+	}
+	record := newStaticRecord("request.duration", sdkapi.HistogramInstrumentKind, number.Float64Kind, agg)
+
+	var out strings.Builder
+	assert.NoError(t, writePrometheus(&out, staticLibraryReader{&staticReader{records: []export.Record{record}}}, false))
+	assert.Equal(t, "# TYPE request_duration histogram\n"+
+		`request_duration_bucket{le="-2"} 1`+"\n"+
+		`request_duration_bucket{le="0"} 2`+"\n"+
+		`request_duration_bucket{le="4"} 4`+"\n"+
+		`request_duration_bucket{le="8"} 5`+"\n"+
+		`request_duration_bucket{le="+Inf"} 5`+"\n"+
+		`request_duration_sum 11`+"\n"+
+		`request_duration_count 5`+"\n", out.String())
+}
+
+func TestSanitizePrometheusName(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		metricName bool
+		want       string
+	}{
+		{name: "dots", input: "orders.processed", metricName: true, want: "orders_processed"},
+		{name: "colons in metric names", input: "http:requests-total", metricName: true, want: "http:requests_total"},
+		{name: "colons in label names", input: "k8s:pod", metricName: false, want: "k8s_pod"},
+		{name: "leading digit", input: "5xx.count", metricName: true, want: "_5xx_count"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, sanitizePrometheusName(tt.input, tt.metricName))
+		})
+	}
+}
+
+func TestOtelMeter_PrometheusHandler_Disabled(t *testing.T) {
+	meter := newTestMeter(newFakeMeter(), config.Config{MetricExporter: config.OTLPExporter})
+
+	recorder := httptest.NewRecorder()
+	meter.PrometheusHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestNewOtelMeter_PrometheusHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		exporter config.MetricExporter
+	}{
+		{name: "scrapes only", exporter: config.PrometheusExporter},
+		{name: "scrapes and pushes", exporter: config.OTLPAndPrometheusExporter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Config{
+				Service:        config.Service{Name: "checkout", Version: "1.0.0"},
+				Mode:           config.Local,
+				MetricExporter: tt.exporter,
+				FlushInterval:  10 * time.Millisecond,
+			}
+			assert.NoError(t, cfg.Ensure())
+			meter, err := NewOtelMeter(cfg)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer meter.Close()
+
+			server := httptest.NewServer(meter.PrometheusHandler())
+			defer server.Close()
+
+			assert.NoError(t, meter.DefaultCounter(context.Background(), "orders.created", 2, nil))
+
+			// Alongside pushes, scrapes serve the last collection, at most a flush interval old
+			assert.Eventually(t, func() bool {
+				response, err := http.Get(server.URL)
+				if !assert.NoError(t, err) {
+					return false
+				}
+				defer response.Body.Close()
+				body, err := io.ReadAll(response.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, response.StatusCode, string(body))
+				return strings.Contains(string(body), "orders_created_total{") &&
+					strings.Contains(string(body), `garden_app_name="checkout"`)
+			}, time.Second, 10*time.Millisecond)
+		})
+	}
+}
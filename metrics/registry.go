@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/garden/observability-commons/config"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/syncfloat64"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+)
+
+type instrumentKind int8
+
+const (
+	histogramKind instrumentKind = iota
+	counterKind
+	gaugeKind
+	observableCounterKind
+	int64HistogramKind
+	float64CounterKind
+	float64GaugeKind
+	upDownCounterKind
+	float64UpDownCounterKind
+)
+
+type instrumentKey struct {
+	name string
+	kind instrumentKind
+}
+
+// instrumentRegistry creates each instrument once and hands out the same one on later calls, so recording a value
+// only costs a map lookup.
+type instrumentRegistry struct {
+	meter metric.Meter
+	views *viewResolver
+
+	mu          sync.RWMutex
+	instruments map[instrumentKey]interface{}
+}
+
+func newInstrumentRegistry(meter metric.Meter, views *viewResolver) *instrumentRegistry {
+	return &instrumentRegistry{
+		meter:       meter,
+		views:       views,
+		instruments: map[instrumentKey]interface{}{},
+	}
+}
+
+// meterFor returns the meter creating the instrument of the given view, a no-op one when the view drops it.
+func (registry *instrumentRegistry) meterFor(view *instrumentView) metric.Meter {
+	if view.aggregation == config.DropAggregation {
+		return metric.NewNoopMeter()
+	}
+	return registry.meter
+}
+
+func (registry *instrumentRegistry) histogram(name string) (syncfloat64.Histogram, error) {
+	return getOrCreate(registry, instrumentKey{name: name, kind: histogramKind}, func() (syncfloat64.Histogram, error) {
+		view := registry.views.resolve(name)
+		return registry.meterFor(view).SyncFloat64().Histogram(view.name, view.options()...)
+	})
+}
+
+func (registry *instrumentRegistry) int64Histogram(name string) (syncint64.Histogram, error) {
+	return getOrCreate(registry, instrumentKey{name: name, kind: int64HistogramKind}, func() (syncint64.Histogram, error) {
+		view := registry.views.resolve(name)
+		return registry.meterFor(view).SyncInt64().Histogram(view.name, view.options()...)
+	})
+}
+
+func (registry *instrumentRegistry) counter(name string) (syncint64.Counter, error) {
+	return getOrCreate(registry, instrumentKey{name: name, kind: counterKind}, func() (syncint64.Counter, error) {
+		view := registry.views.resolve(name)
+		return registry.meterFor(view).SyncInt64().Counter(view.name, view.options()...)
+	})
+}
+
+func (registry *instrumentRegistry) float64Counter(name string) (syncfloat64.Counter, error) {
+	return getOrCreate(registry, instrumentKey{name: name, kind: float64CounterKind}, func() (syncfloat64.Counter, error) {
+		view := registry.views.resolve(name)
+		return registry.meterFor(view).SyncFloat64().Counter(view.name, view.options()...)
+	})
+}
+
+func (registry *instrumentRegistry) upDownCounter(name string) (syncint64.UpDownCounter, error) {
+	return getOrCreate(registry, instrumentKey{name: name, kind: upDownCounterKind}, func() (syncint64.UpDownCounter, error) {
+		view := registry.views.resolve(name)
+		return registry.meterFor(view).SyncInt64().UpDownCounter(view.name, view.options()...)
+	})
+}
+
+func (registry *instrumentRegistry) float64UpDownCounter(name string) (syncfloat64.UpDownCounter, error) {
+	return getOrCreate(registry, instrumentKey{name: name, kind: float64UpDownCounterKind}, func() (syncfloat64.UpDownCounter, error) {
+		view := registry.views.resolve(name)
+		return registry.meterFor(view).SyncFloat64().UpDownCounter(view.name, view.options()...)
+	})
+}
+
+// gauge returns the asynchronous gauge of the given name, registering its callback the first time it is requested.
+func (registry *instrumentRegistry) gauge(name string) (*observableInstrument[int64], error) {
+	return getOrCreate(registry, instrumentKey{name: name, kind: gaugeKind}, func() (*observableInstrument[int64], error) {
+		view := registry.views.resolve(name)
+		asyncGauge, err := registry.meterFor(view).AsyncInt64().Gauge(view.name, view.options()...)
+		if err != nil {
+			return nil, err
+		}
+		return observe[int64](registry, view, asyncGauge, asyncGauge)
+	})
+}
+
+func (registry *instrumentRegistry) float64Gauge(name string) (*observableInstrument[float64], error) {
+	return getOrCreate(registry, instrumentKey{name: name, kind: float64GaugeKind}, func() (*observableInstrument[float64], error) {
+		view := registry.views.resolve(name)
+		asyncGauge, err := registry.meterFor(view).AsyncFloat64().Gauge(view.name, view.options()...)
+		if err != nil {
+			return nil, err
+		}
+		return observe[float64](registry, view, asyncGauge, asyncGauge)
+	})
+}
+
+// observableCounter returns the asynchronous counter of the given name, registering its callback the first time it is
+// requested.
+func (registry *instrumentRegistry) observableCounter(name string) (*observableInstrument[int64], error) {
+	return getOrCreate(registry, instrumentKey{name: name, kind: observableCounterKind}, func() (*observableInstrument[int64], error) {
+		view := registry.views.resolve(name)
+		asyncCounter, err := registry.meterFor(view).AsyncInt64().Counter(view.name, view.options()...)
+		if err != nil {
+			return nil, err
+		}
+		return observe[int64](registry, view, asyncCounter, asyncCounter)
+	})
+}
+
+// lookup returns the instrument of the given name and kind without creating it.
+func (registry *instrumentRegistry) lookup(name string, kind instrumentKind) (interface{}, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	cached, ok := registry.instruments[instrumentKey{name: name, kind: kind}]
+	return cached, ok
+}
+
+func observe[N numeric](registry *instrumentRegistry, view *instrumentView, asynchronous instrument.Asynchronous, observer observer[N]) (*observableInstrument[N], error) {
+	observable := newObservableInstrument(observer)
+	if err := registry.meterFor(view).RegisterCallback([]instrument.Asynchronous{asynchronous}, observable.observe); err != nil {
+		return nil, err
+	}
+	return observable, nil
+}
+
+func getOrCreate[T any](registry *instrumentRegistry, key instrumentKey, create func() (T, error)) (T, error) {
+	registry.mu.RLock()
+	cached, ok := registry.instruments[key]
+	registry.mu.RUnlock()
+	if ok {
+		return cached.(T), nil
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	// Another goroutine may have created it while we were waiting for the lock
+	if cached, ok := registry.instruments[key]; ok {
+		return cached.(T), nil
+	}
+
+	created, err := create()
+	if err != nil {
+		return created, err
+	}
+	registry.instruments[key] = created
+	return created, nil
+}
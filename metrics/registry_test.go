@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/garden/observability-commons/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstrumentRegistry_CachesByNameAndKind(t *testing.T) {
+	meter := newFakeMeter()
+	registry := newInstrumentRegistry(meter, newViewResolver(config.Config{}))
+
+	for i := 0; i < 3; i++ {
+		_, err := registry.histogram("request.duration")
+		assert.NoError(t, err)
+		_, err = registry.counter("request.duration")
+		assert.NoError(t, err)
+		_, err = registry.gauge("request.duration")
+		assert.NoError(t, err)
+		_, err = registry.counter("request.count")
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, uint64(4), atomic.LoadUint64(&meter.created))
+	assert.Len(t, registry.instruments, 4)
+}
+
+func TestInstrumentRegistry_Concurrent(t *testing.T) {
+	meter := newFakeMeter()
+	registry := newInstrumentRegistry(meter, newViewResolver(config.Config{}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, err := registry.counter("request.count")
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&meter.created))
+}
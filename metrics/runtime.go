@@ -0,0 +1,235 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	runtimemetrics "runtime/metrics"
+	"strconv"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/unit"
+)
+
+const (
+	goroutinesSample     = "/sched/goroutines:goroutines"
+	memoryTotalSample    = "/memory/classes/total:bytes"
+	memoryReleasedSample = "/memory/classes/heap/released:bytes"
+	heapStacksSample     = "/memory/classes/heap/stacks:bytes"
+	osStacksSample       = "/memory/classes/os-stacks:bytes"
+	memoryLimitSample    = "/gc/gomemlimit:bytes"
+	allocatedSample      = "/gc/heap/allocs:bytes"
+	allocationsSample    = "/gc/heap/allocs:objects"
+	gcGoalSample         = "/gc/heap/goal:bytes"
+	gcCyclesSample       = "/gc/cycles/total:gc-cycles"
+	gogcSample           = "/gc/gogc:percent"
+	gcPausesSample       = "/gc/pauses:seconds"
+	schedLatenciesSample = "/sched/latencies:seconds"
+)
+
+var (
+	runtimeQuantiles = []float64{0.5, 0.9, 0.99}
+	memoryTypeKey    = attribute.Key("go.memory.type")
+	quantileKey      = attribute.Key("quantile")
+)
+
+// runtimeCollector publishes the Go runtime metrics under the OpenTelemetry semantic convention names, reading
+// runtime/metrics once per collection. The GC pause and scheduling latency distributions are reported as quantile
+// gauges, since asynchronous instruments can't observe histograms, of the samples recorded since the previous
+// collection: the runtime histograms count every sample since the process started.
+type runtimeCollector struct {
+	instruments  *asyncInstruments
+	goroutines   collectedInstrument[int64]
+	memoryUsed   collectedInstrument[int64]
+	memoryLimit  collectedInstrument[int64]
+	allocated    collectedInstrument[int64]
+	allocations  collectedInstrument[int64]
+	gcGoal       collectedInstrument[int64]
+	gcCount      collectedInstrument[int64]
+	gogc         collectedInstrument[int64]
+	processors   collectedInstrument[int64]
+	gcPauses     collectedInstrument[float64]
+	schedLatency collectedInstrument[float64]
+
+	mu       sync.Mutex
+	samples  []runtimemetrics.Sample
+	index    map[string]int
+	previous map[string][]uint64
+}
+
+// StartRuntimeCollector publishes the Go runtime metrics, e.g. go.goroutine.count and go.memory.used, with the default
+// attributes. It must be called once per meter.
+func (meter OtelMeter) StartRuntimeCollector() error {
+	collector, err := newRuntimeCollector(newAsyncInstruments(meter.meter, meter.views, meter.defaultAttrs))
+	if err != nil {
+		return fmt.Errorf("error creating runtime collector: %w", err)
+	}
+	if err = collector.instruments.register(collector.observe); err != nil {
+		return fmt.Errorf("error creating runtime collector: %w", err)
+	}
+	return nil
+}
+
+func newRuntimeCollector(instruments *asyncInstruments) (*runtimeCollector, error) {
+	collector := &runtimeCollector{
+		instruments: instruments,
+		index:       map[string]int{},
+		previous:    map[string][]uint64{},
+	}
+
+	supported := map[string]struct{}{}
+	for _, description := range runtimemetrics.All() {
+		supported[description.Name] = struct{}{}
+	}
+	for _, name := range []string{
+		goroutinesSample, memoryTotalSample, memoryReleasedSample, heapStacksSample, osStacksSample, memoryLimitSample,
+		allocatedSample, allocationsSample, gcGoalSample, gcCyclesSample, gogcSample, gcPausesSample, schedLatenciesSample,
+	} {
+		if _, ok := supported[name]; ok {
+			collector.index[name] = len(collector.samples)
+			collector.samples = append(collector.samples, runtimemetrics.Sample{Name: name})
+		}
+	}
+
+	var err error
+	create := func(created *collectedInstrument[int64], name string, instrumentUnit unit.Unit, kind asynchronousFunc[int64], samples ...string) {
+		if err == nil && collector.supports(samples...) {
+			*created, err = newCollectedInstrument(instruments, name, instrumentUnit, kind)
+		}
+	}
+	create(&collector.goroutines, "go.goroutine.count", "{goroutine}", int64Gauge, goroutinesSample)
+	create(&collector.memoryUsed, "go.memory.used", unit.Bytes, int64Gauge, memoryTotalSample, memoryReleasedSample, heapStacksSample, osStacksSample)
+	create(&collector.memoryLimit, "go.memory.limit", unit.Bytes, int64Gauge, memoryLimitSample)
+	create(&collector.allocated, "go.memory.allocated", unit.Bytes, int64Counter, allocatedSample)
+	create(&collector.allocations, "go.memory.allocations", "{allocation}", int64Counter, allocationsSample)
+	create(&collector.gcGoal, "go.memory.gc.goal", unit.Bytes, int64Gauge, gcGoalSample)
+	create(&collector.gcCount, "go.gc.count", "{gc_cycle}", int64Counter, gcCyclesSample)
+	create(&collector.gogc, "go.config.gogc", "%", int64Gauge, gogcSample)
+	create(&collector.processors, "go.processor.limit", "{thread}", int64Gauge)
+	if err != nil {
+		return nil, err
+	}
+
+	if collector.supports(gcPausesSample) {
+		if collector.gcPauses, err = newCollectedInstrument(instruments, "go.gc.pause.duration", "s", float64Gauge); err != nil {
+			return nil, err
+		}
+	}
+	if collector.supports(schedLatenciesSample) {
+		if collector.schedLatency, err = newCollectedInstrument(instruments, "go.schedule.duration", "s", float64Gauge); err != nil {
+			return nil, err
+		}
+	}
+
+	return collector, nil
+}
+
+func (collector *runtimeCollector) supports(samples ...string) bool {
+	for _, sample := range samples {
+		if _, ok := collector.index[sample]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (collector *runtimeCollector) observe(ctx context.Context) {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	runtimemetrics.Read(collector.samples)
+
+	collector.goroutines.observe(ctx, collector.int64(goroutinesSample))
+	stacks := collector.int64(heapStacksSample) + collector.int64(osStacksSample)
+	used := collector.int64(memoryTotalSample) - collector.int64(memoryReleasedSample)
+	collector.memoryUsed.observe(ctx, stacks, memoryTypeKey.String("stack"))
+	collector.memoryUsed.observe(ctx, used-stacks, memoryTypeKey.String("other"))
+	collector.memoryLimit.observe(ctx, collector.int64(memoryLimitSample))
+	collector.allocated.observe(ctx, collector.int64(allocatedSample))
+	collector.allocations.observe(ctx, collector.int64(allocationsSample))
+	collector.gcGoal.observe(ctx, collector.int64(gcGoalSample))
+	collector.gcCount.observe(ctx, collector.int64(gcCyclesSample))
+	collector.gogc.observe(ctx, collector.int64(gogcSample))
+	collector.processors.observe(ctx, int64(runtime.GOMAXPROCS(0)))
+
+	gcPauses := collector.intervalHistogram(gcPausesSample)
+	schedLatencies := collector.intervalHistogram(schedLatenciesSample)
+	for _, quantile := range runtimeQuantiles {
+		attr := quantileKey.String(strconv.FormatFloat(quantile, 'g', -1, 64))
+		if gcPauses != nil {
+			collector.gcPauses.observe(ctx, histogramQuantile(gcPauses, quantile), attr)
+		}
+		if schedLatencies != nil {
+			collector.schedLatency.observe(ctx, histogramQuantile(schedLatencies, quantile), attr)
+		}
+	}
+}
+
+func (collector *runtimeCollector) int64(name string) int64 {
+	i, ok := collector.index[name]
+	if !ok || collector.samples[i].Value.Kind() != runtimemetrics.KindUint64 {
+		return 0
+	}
+	return int64(collector.samples[i].Value.Uint64())
+}
+
+func (collector *runtimeCollector) histogram(name string) *runtimemetrics.Float64Histogram {
+	i, ok := collector.index[name]
+	if !ok || collector.samples[i].Value.Kind() != runtimemetrics.KindFloat64Histogram {
+		return nil
+	}
+	return collector.samples[i].Value.Float64Histogram()
+}
+
+// intervalHistogram returns the histogram of the samples recorded since the previous collection.
+func (collector *runtimeCollector) intervalHistogram(name string) *runtimemetrics.Float64Histogram {
+	histogram := collector.histogram(name)
+	if histogram == nil {
+		return nil
+	}
+	return &runtimemetrics.Float64Histogram{Counts: collector.intervalCounts(name, histogram.Counts), Buckets: histogram.Buckets}
+}
+
+// intervalCounts subtracts the counts of the previous collection from the cumulative ones, keeping a copy of the latter
+// since runtime/metrics reuses them.
+func (collector *runtimeCollector) intervalCounts(name string, counts []uint64) []uint64 {
+	previous := collector.previous[name]
+	interval := make([]uint64, len(counts))
+	for i, count := range counts {
+		interval[i] = count
+		if len(previous) == len(counts) {
+			interval[i] -= previous[i]
+		}
+	}
+	collector.previous[name] = append(previous[:0], counts...)
+	return interval
+}
+
+// histogramQuantile estimates the quantile of a runtime histogram as the upper boundary of the bucket it falls in, or
+// its lower boundary for the last, unbounded bucket. Histograms without samples report 0.
+func histogramQuantile(histogram *runtimemetrics.Float64Histogram, quantile float64) float64 {
+	var total uint64
+	for _, count := range histogram.Counts {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(quantile * float64(total)))
+	var cumulative uint64
+	for i, count := range histogram.Counts {
+		cumulative += count
+		if cumulative < rank || count == 0 {
+			continue
+		}
+
+		if upper := histogram.Buckets[i+1]; !math.IsInf(upper, 1) {
+			return upper
+		}
+		return histogram.Buckets[i]
+	}
+	return histogram.Buckets[len(histogram.Buckets)-1]
+}
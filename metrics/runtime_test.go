@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"math"
+	runtimemetrics "runtime/metrics"
+	"testing"
+
+	"github.com/garden/observability-commons/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOtelMeter_StartRuntimeCollector(t *testing.T) {
+	collecting := newFakeMeter()
+	meter := newTestMeter(collecting, config.Config{
+		Service: config.Service{Name: "checkout", Version: "1.0.0"},
+		Views:   []config.View{{Pattern: "go.schedule.duration", Aggregation: config.DropAggregation}},
+	})
+
+	assert.NoError(t, meter.StartRuntimeCollector())
+	observed := collecting.collect()
+
+	for _, name := range []string{"go.goroutine.count", "go.memory.used", "go.memory.allocated", "go.gc.count", "go.processor.limit"} {
+		if assert.NotEmpty(t, observed[name], name) {
+			assert.Equal(t, "checkout", observed[name][0].attrs["garden.app.name"], name)
+		}
+	}
+	assert.Positive(t, observed["go.goroutine.count"][0].value)
+
+	memoryTypes := map[string]bool{}
+	for _, observation := range observed["go.memory.used"] {
+		memoryTypes[observation.attrs["go.memory.type"]] = true
+	}
+	assert.Equal(t, map[string]bool{"stack": true, "other": true}, memoryTypes)
+
+	assert.Len(t, observed["go.gc.pause.duration"], len(runtimeQuantiles))
+	assert.NotContains(t, observed, "go.schedule.duration", "views can drop runtime metrics")
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	histogram := &runtimemetrics.Float64Histogram{
+		Counts:  []uint64{5, 0, 4, 1},
+		Buckets: []float64{0, 1, 2, 3, math.Inf(1)},
+	}
+
+	tests := []struct {
+		name      string
+		histogram *runtimemetrics.Float64Histogram
+		quantile  float64
+		want      float64
+	}{
+		{name: "median", histogram: histogram, quantile: 0.5, want: 1},
+		{name: "skips empty buckets", histogram: histogram, quantile: 0.6, want: 3},
+		{name: "unbounded bucket", histogram: histogram, quantile: 0.99, want: 3},
+		{name: "no samples", histogram: &runtimemetrics.Float64Histogram{Counts: []uint64{0}, Buckets: []float64{0, 1}}, quantile: 0.5, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, histogramQuantile(tt.histogram, tt.quantile))
+		})
+	}
+}
+
+func TestRuntimeCollector_intervalCounts(t *testing.T) {
+	collector := &runtimeCollector{previous: map[string][]uint64{}}
+	counts := []uint64{2, 1, 0}
+
+	assert.Equal(t, []uint64{2, 1, 0}, collector.intervalCounts(gcPausesSample, counts), "the first interval starts with the process")
+
+	// runtime/metrics updates the counts in place
+	counts[0], counts[2] = 3, 4
+	assert.Equal(t, []uint64{1, 0, 4}, collector.intervalCounts(gcPausesSample, counts))
+	assert.Equal(t, []uint64{0, 0, 0}, collector.intervalCounts(gcPausesSample, counts), "nothing recorded meanwhile")
+	assert.Equal(t, []uint64{3, 1, 4}, collector.intervalCounts(schedLatenciesSample, counts), "each histogram has its own")
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"github.com/garden/observability-commons/config"
+	"go.opentelemetry.io/otel/sdk/metric/export/aggregation"
+	"go.opentelemetry.io/otel/sdk/metric/sdkapi"
+)
+
+func newTemporalitySelector(temporality config.Temporality) aggregation.TemporalitySelector {
+	switch temporality {
+	case config.Delta:
+		return aggregation.DeltaTemporalitySelector()
+	case config.LowMemory:
+		return lowMemoryTemporalitySelector{}
+	default:
+		return aggregation.CumulativeTemporalitySelector()
+	}
+}
+
+// lowMemoryTemporalitySelector exports deltas for the instruments whose cumulative values would have to be kept in
+// memory, and cumulative values for the others.
+type lowMemoryTemporalitySelector struct{}
+
+func (lowMemoryTemporalitySelector) TemporalityFor(descriptor *sdkapi.Descriptor, _ aggregation.Kind) aggregation.Temporality {
+	switch descriptor.InstrumentKind() {
+	case sdkapi.CounterInstrumentKind, sdkapi.HistogramInstrumentKind:
+		return aggregation.DeltaTemporality
+	default:
+		return aggregation.CumulativeTemporality
+	}
+}
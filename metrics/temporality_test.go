@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/garden/observability-commons/config"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/sdk/metric/export/aggregation"
+	"go.opentelemetry.io/otel/sdk/metric/number"
+	"go.opentelemetry.io/otel/sdk/metric/sdkapi"
+)
+
+func TestNewTemporalitySelector(t *testing.T) {
+	tests := []struct {
+		name        string
+		temporality config.Temporality
+		kind        sdkapi.InstrumentKind
+		want        aggregation.Temporality
+	}{
+		{
+			name:        "cumulative counter",
+			temporality: config.Cumulative,
+			kind:        sdkapi.CounterInstrumentKind,
+			want:        aggregation.CumulativeTemporality,
+		},
+		{
+			name:        "delta counter",
+			temporality: config.Delta,
+			kind:        sdkapi.CounterInstrumentKind,
+			want:        aggregation.DeltaTemporality,
+		},
+		{
+			name:        "delta asynchronous counter",
+			temporality: config.Delta,
+			kind:        sdkapi.CounterObserverInstrumentKind,
+			want:        aggregation.DeltaTemporality,
+		},
+		{
+			name:        "low memory counter",
+			temporality: config.LowMemory,
+			kind:        sdkapi.CounterInstrumentKind,
+			want:        aggregation.DeltaTemporality,
+		},
+		{
+			name:        "low memory histogram",
+			temporality: config.LowMemory,
+			kind:        sdkapi.HistogramInstrumentKind,
+			want:        aggregation.DeltaTemporality,
+		},
+		{
+			name:        "low memory up-down counter",
+			temporality: config.LowMemory,
+			kind:        sdkapi.UpDownCounterInstrumentKind,
+			want:        aggregation.CumulativeTemporality,
+		},
+		{
+			name:        "low memory asynchronous counter",
+			temporality: config.LowMemory,
+			kind:        sdkapi.CounterObserverInstrumentKind,
+			want:        aggregation.CumulativeTemporality,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			descriptor := sdkapi.NewDescriptor("orders.created", tt.kind, number.Int64Kind, "", "")
+			got := newTemporalitySelector(tt.temporality).TemporalityFor(&descriptor, aggregation.SumKind)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package metrics
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/garden/observability-commons/config"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/unit"
+	"go.opentelemetry.io/otel/sdk/metric/aggregator"
+	"go.opentelemetry.io/otel/sdk/metric/aggregator/histogram"
+	"go.opentelemetry.io/otel/sdk/metric/aggregator/lastvalue"
+	"go.opentelemetry.io/otel/sdk/metric/aggregator/sum"
+	"go.opentelemetry.io/otel/sdk/metric/sdkapi"
+)
+
+// instrumentView is how an instrument is exported once the histogram definition and the first matching view are
+// applied to it.
+type instrumentView struct {
+	name        string
+	unit        unit.Unit
+	aggregation config.Aggregation
+	boundaries  []float64
+	dropped     map[attribute.Key]struct{}
+}
+
+type histogramDefinition struct {
+	unit       unit.Unit
+	boundaries []float64
+}
+
+// viewResolver resolves the view of every instrument once, the first time it is used, so recording values does not
+// match patterns again.
+type viewResolver struct {
+	views             []config.View
+	defaultBoundaries []float64
+	// exponential makes histograms without boundaries of their own exponential ones
+	exponential bool
+	maxScale    int32
+	maxSize     int32
+
+	mu         sync.RWMutex
+	histograms map[string]histogramDefinition
+	resolved   map[string]*instrumentView
+	// exported indexes the resolved views by exported name, which is all the SDK knows when selecting aggregators
+	exported map[string]*instrumentView
+}
+
+func newViewResolver(cfg config.Config) *viewResolver {
+	return &viewResolver{
+		views:             cfg.Views,
+		defaultBoundaries: cfg.HistogramBoundaries,
+		exponential:       cfg.ExponentialHistograms,
+		maxScale:          cfg.GetExponentialHistogramMaxScale(),
+		maxSize:           cfg.ExponentialHistogramMaxSize,
+		histograms:        map[string]histogramDefinition{},
+		resolved:          map[string]*instrumentView{},
+		exported:          map[string]*instrumentView{},
+	}
+}
+
+// defineHistogram sets the unit and bucket boundaries of the histogram of the given name. It has to be called before
+// the first value is recorded, since instruments can't be changed once created.
+func (resolver *viewResolver) defineHistogram(name string, histogramUnit unit.Unit, boundaries []float64) error {
+	if err := config.ValidateBoundaries(boundaries); err != nil {
+		return fmt.Errorf("error registering histogram %s: %w", name, err)
+	}
+
+	resolver.mu.Lock()
+	defer resolver.mu.Unlock()
+
+	if _, ok := resolver.resolved[name]; ok {
+		return fmt.Errorf("error registering histogram %s: already in use", name)
+	}
+	resolver.histograms[name] = histogramDefinition{unit: histogramUnit, boundaries: boundaries}
+	return nil
+}
+
+func (resolver *viewResolver) resolve(name string) *instrumentView {
+	resolver.mu.RLock()
+	view, ok := resolver.resolved[name]
+	resolver.mu.RUnlock()
+	if ok {
+		return view
+	}
+
+	resolver.mu.Lock()
+	defer resolver.mu.Unlock()
+
+	if view, ok := resolver.resolved[name]; ok {
+		return view
+	}
+
+	definition := resolver.histograms[name]
+	view = &instrumentView{
+		name:       name,
+		unit:       definition.unit,
+		boundaries: definition.boundaries,
+	}
+
+	for _, configured := range resolver.views {
+		if !configured.Matches(name) {
+			continue
+		}
+
+		if configured.Name != "" {
+			view.name = configured.Name
+		}
+		view.aggregation = configured.Aggregation
+		if configured.Boundaries != nil {
+			view.boundaries = configured.Boundaries
+		}
+		if len(configured.DropAttributes) > 0 {
+			view.dropped = make(map[attribute.Key]struct{}, len(configured.DropAttributes))
+			for _, key := range configured.DropAttributes {
+				view.dropped[attribute.Key(key)] = struct{}{}
+			}
+		}
+		break
+	}
+
+	resolver.resolved[name] = view
+	resolver.exported[view.name] = view
+	return view
+}
+
+func (resolver *viewResolver) lookupExported(name string) (*instrumentView, bool) {
+	resolver.mu.RLock()
+	defer resolver.mu.RUnlock()
+
+	view, ok := resolver.exported[name]
+	return view, ok
+}
+
+func (view *instrumentView) options() []instrument.Option {
+	if view.unit == "" {
+		return nil
+	}
+	return []instrument.Option{instrument.WithUnit(view.unit)}
+}
+
+// filter removes the attributes dropped by the view, reusing attrs.
+func (view *instrumentView) filter(attrs []attribute.KeyValue) []attribute.KeyValue {
+	if len(view.dropped) == 0 {
+		return attrs
+	}
+
+	filtered := attrs[:0]
+	for _, attr := range attrs {
+		if _, ok := view.dropped[attr.Key]; !ok {
+			filtered = append(filtered, attr)
+		}
+	}
+	return filtered
+}
+
+// aggregatorSelector picks the aggregation of every instrument from its view, falling back to the same choices as the
+// SDK's simple.NewWithHistogramDistribution. Histograms without boundaries of their own use the default boundaries, or
+// exponential buckets when enabled.
+type aggregatorSelector struct {
+	resolver *viewResolver
+}
+
+func (selector aggregatorSelector) AggregatorFor(descriptor *sdkapi.Descriptor, aggPtrs ...*aggregator.Aggregator) {
+	aggregation := config.DefaultAggregation
+	var boundaries []float64
+	if view, ok := selector.resolver.lookupExported(descriptor.Name()); ok {
+		aggregation = view.aggregation
+		boundaries = view.boundaries
+	}
+
+	if aggregation == config.DefaultAggregation {
+		switch descriptor.InstrumentKind() {
+		case sdkapi.GaugeObserverInstrumentKind:
+			aggregation = config.LastValueAggregation
+		case sdkapi.HistogramInstrumentKind:
+			aggregation = config.HistogramAggregation
+			if selector.resolver.exponential && boundaries == nil {
+				aggregation = config.ExponentialHistogramAggregation
+			}
+		default:
+			aggregation = config.SumAggregation
+		}
+	}
+
+	switch aggregation {
+	case config.LastValueAggregation:
+		aggs := lastvalue.New(len(aggPtrs))
+		for i := range aggPtrs {
+			*aggPtrs[i] = &aggs[i]
+		}
+	case config.HistogramAggregation:
+		if boundaries == nil {
+			boundaries = selector.resolver.defaultBoundaries
+		}
+		var options []histogram.Option
+		if boundaries != nil {
+			options = append(options, histogram.WithExplicitBoundaries(boundaries))
+		}
+		aggs := histogram.New(len(aggPtrs), descriptor, options...)
+		for i := range aggPtrs {
+			*aggPtrs[i] = &aggs[i]
+		}
+	case config.ExponentialHistogramAggregation:
+		aggs := newExponentialAggregators(len(aggPtrs), selector.resolver.maxScale, selector.resolver.maxSize)
+		for i := range aggPtrs {
+			*aggPtrs[i] = &aggs[i]
+		}
+	case config.DropAggregation:
+		// Leaving the aggregators nil makes the SDK skip the instrument
+	default:
+		aggs := sum.New(len(aggPtrs))
+		for i := range aggPtrs {
+			*aggPtrs[i] = &aggs[i]
+		}
+	}
+}
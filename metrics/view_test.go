@@ -0,0 +1,261 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/garden/observability-commons/config"
+	"github.com/garden/observability-commons/util"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/unit"
+	"go.opentelemetry.io/otel/sdk/metric/aggregator"
+	"go.opentelemetry.io/otel/sdk/metric/aggregator/histogram"
+	"go.opentelemetry.io/otel/sdk/metric/aggregator/lastvalue"
+	"go.opentelemetry.io/otel/sdk/metric/aggregator/sum"
+	"go.opentelemetry.io/otel/sdk/metric/number"
+	"go.opentelemetry.io/otel/sdk/metric/sdkapi"
+)
+
+func TestViewResolver_resolve(t *testing.T) {
+	latencyBoundaries := []float64{5, 10, 25, 50, 100, 250, 500, 1000}
+	sizeBoundaries := []float64{1024, 16384, 262144, 1048576}
+	defaultBoundaries := []float64{1, 10, 100}
+
+	tests := []struct {
+		name       string
+		cfg        config.Config
+		histograms map[string][]float64
+		metricName string
+		want       instrumentView
+	}{
+		{
+			name:       "no views",
+			cfg:        config.Config{},
+			metricName: "request.duration",
+			want:       instrumentView{name: "request.duration"},
+		},
+		{
+			name:       "default boundaries are left to the aggregator selector",
+			cfg:        config.Config{HistogramBoundaries: defaultBoundaries},
+			metricName: "request.duration",
+			want:       instrumentView{name: "request.duration"},
+		},
+		{
+			name:       "registered histogram",
+			cfg:        config.Config{HistogramBoundaries: defaultBoundaries},
+			histograms: map[string][]float64{"request.duration": latencyBoundaries},
+			metricName: "request.duration",
+			want:       instrumentView{name: "request.duration", unit: unit.Milliseconds, boundaries: latencyBoundaries},
+		},
+		{
+			name: "view renames, drops attributes and overrides boundaries",
+			cfg: config.Config{
+				Views: []config.View{
+					{
+						Pattern:        "http.*",
+						Name:           "http.server.duration",
+						DropAttributes: []string{"user_id"},
+						Aggregation:    config.HistogramAggregation,
+						Boundaries:     sizeBoundaries,
+					},
+				},
+			},
+			histograms: map[string][]float64{"http.latency": latencyBoundaries},
+			metricName: "http.latency",
+			want: instrumentView{
+				name:        "http.server.duration",
+				unit:        unit.Milliseconds,
+				aggregation: config.HistogramAggregation,
+				boundaries:  sizeBoundaries,
+				dropped:     map[attribute.Key]struct{}{"user_id": {}},
+			},
+		},
+		{
+			name: "first matching view wins",
+			cfg: config.Config{
+				Views: []config.View{
+					{Pattern: "orders.*", Aggregation: config.DropAggregation},
+					{Pattern: "*", Aggregation: config.SumAggregation},
+				},
+			},
+			metricName: "orders.created",
+			want:       instrumentView{name: "orders.created", aggregation: config.DropAggregation},
+		},
+		{
+			name: "view not matching",
+			cfg: config.Config{
+				Views: []config.View{
+					{Pattern: "orders.*", Aggregation: config.DropAggregation},
+				},
+			},
+			metricName: "payments.created",
+			want:       instrumentView{name: "payments.created"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := newViewResolver(tt.cfg)
+			for name, boundaries := range tt.histograms {
+				assert.NoError(t, resolver.defineHistogram(name, unit.Milliseconds, boundaries))
+			}
+
+			got := resolver.resolve(tt.metricName)
+			assert.Equal(t, tt.want, *got)
+			assert.Same(t, got, resolver.resolve(tt.metricName), "views must be resolved once")
+		})
+	}
+}
+
+func TestViewResolver_defineHistogram(t *testing.T) {
+	resolver := newViewResolver(config.Config{})
+
+	assert.Error(t, resolver.defineHistogram("request.duration", unit.Milliseconds, []float64{10, 5}))
+
+	resolver.resolve("request.duration")
+	assert.Error(t, resolver.defineHistogram("request.duration", unit.Milliseconds, []float64{5, 10}),
+		"an instrument already in use can't change")
+}
+
+func TestAggregatorSelector_AggregatorFor(t *testing.T) {
+	resolver := newViewResolver(config.Config{
+		HistogramBoundaries:         []float64{1, 10, 100},
+		ExponentialHistogramMaxSize: config.DefaultExponentialHistogramMaxSize,
+		Views: []config.View{
+			{Pattern: "queue.depth", Aggregation: config.LastValueAggregation},
+			{Pattern: "disabled.*", Aggregation: config.DropAggregation},
+			{Pattern: "latency.*", Aggregation: config.ExponentialHistogramAggregation},
+		},
+	})
+	assert.NoError(t, resolver.defineHistogram("request.duration", unit.Milliseconds, []float64{5, 50, 500}))
+	for _, name := range []string{"request.duration", "queue.depth", "disabled.metric", "latency.checkout"} {
+		resolver.resolve(name)
+	}
+	selector := aggregatorSelector{resolver: resolver}
+
+	tests := []struct {
+		name           string
+		metricName     string
+		kind           sdkapi.InstrumentKind
+		wantType       aggregator.Aggregator
+		wantBoundaries []float64
+	}{
+		{
+			name:           "registered histogram",
+			metricName:     "request.duration",
+			kind:           sdkapi.HistogramInstrumentKind,
+			wantType:       &histogram.Aggregator{},
+			wantBoundaries: []float64{5, 50, 500},
+		},
+		{
+			name:           "histogram with default boundaries",
+			metricName:     "response.size",
+			kind:           sdkapi.HistogramInstrumentKind,
+			wantType:       &histogram.Aggregator{},
+			wantBoundaries: []float64{1, 10, 100},
+		},
+		{
+			name:       "counter",
+			metricName: "orders.created",
+			kind:       sdkapi.CounterInstrumentKind,
+			wantType:   &sum.Aggregator{},
+		},
+		{
+			name:       "gauge",
+			metricName: "orders.active",
+			kind:       sdkapi.GaugeObserverInstrumentKind,
+			wantType:   &lastvalue.Aggregator{},
+		},
+		{
+			name:       "view aggregation",
+			metricName: "queue.depth",
+			kind:       sdkapi.UpDownCounterInstrumentKind,
+			wantType:   &lastvalue.Aggregator{},
+		},
+		{
+			name:       "view exponential histogram",
+			metricName: "latency.checkout",
+			kind:       sdkapi.HistogramInstrumentKind,
+			wantType:   &exponentialAggregator{},
+		},
+		{
+			name:       "dropped",
+			metricName: "disabled.metric",
+			kind:       sdkapi.CounterInstrumentKind,
+			wantType:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			descriptor := sdkapi.NewDescriptor(tt.metricName, tt.kind, number.Float64Kind, "", "")
+			var current, checkpoint aggregator.Aggregator
+			selector.AggregatorFor(&descriptor, &current, &checkpoint)
+
+			if tt.wantType == nil {
+				assert.Nil(t, current)
+				assert.Nil(t, checkpoint)
+				return
+			}
+			assert.IsType(t, tt.wantType, current)
+			assert.IsType(t, tt.wantType, checkpoint)
+			if tt.wantBoundaries != nil {
+				buckets, err := current.(*histogram.Aggregator).Histogram()
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantBoundaries, buckets.Boundaries)
+			}
+		})
+	}
+}
+
+func TestAggregatorSelector_ExponentialHistograms(t *testing.T) {
+	maxScale := int32(10)
+	resolver := newViewResolver(config.Config{
+		HistogramBoundaries:          []float64{1, 10, 100},
+		ExponentialHistograms:        true,
+		ExponentialHistogramMaxScale: &maxScale,
+		ExponentialHistogramMaxSize:  80,
+	})
+	assert.NoError(t, resolver.defineHistogram("request.duration", unit.Milliseconds, []float64{5, 50, 500}))
+	resolver.resolve("request.duration")
+	selector := aggregatorSelector{resolver: resolver}
+
+	var registered, unregistered aggregator.Aggregator
+	descriptor := sdkapi.NewDescriptor("request.duration", sdkapi.HistogramInstrumentKind, number.Float64Kind, "", "")
+	selector.AggregatorFor(&descriptor, &registered)
+	descriptor = sdkapi.NewDescriptor("response.size", sdkapi.HistogramInstrumentKind, number.Float64Kind, "", "")
+	selector.AggregatorFor(&descriptor, &unregistered)
+
+	assert.IsType(t, &histogram.Aggregator{}, registered, "histograms with their own boundaries stay explicit")
+	if assert.IsType(t, &exponentialAggregator{}, unregistered) {
+		exponential := unregistered.(*exponentialAggregator)
+		assert.Equal(t, int32(10), exponential.maxScale)
+		assert.Equal(t, int32(80), exponential.maxSize)
+		assert.Equal(t, int32(10), exponential.snapshot().scale)
+	}
+}
+
+func TestOtelMeter_Views(t *testing.T) {
+	recording := newFakeMeter()
+	meter := newTestMeter(recording, config.Config{
+		Views: []config.View{
+			{Pattern: "http.requests", Name: "http.server.requests", DropAttributes: []string{"user_id"}},
+			{Pattern: "debug.*", Aggregation: config.DropAggregation},
+		},
+	})
+	ctx := context.Background()
+
+	assert.NoError(t, meter.DefaultCounter(ctx, "http.requests", 1, util.ExtraFields{"route": "/orders", "user_id": "42"}))
+	assert.NoError(t, meter.DefaultCounter(ctx, "debug.allocations", 1, nil))
+
+	if assert.Len(t, recording.measurements, 1) {
+		got := recording.measurements[0]
+		assert.Equal(t, "http.server.requests", got.name)
+
+		attrs := map[attribute.Key]string{}
+		for _, attr := range got.attrs {
+			attrs[attr.Key] = attr.Value.Emit()
+		}
+		assert.Equal(t, "/orders", attrs["route"])
+		assert.NotContains(t, attrs, attribute.Key("user_id"))
+	}
+}
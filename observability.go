@@ -2,11 +2,14 @@ package observability
 
 import (
 	"context"
+	"io"
+	"net/http"
 
 	"github.com/garden/observability-commons/config"
 	"github.com/garden/observability-commons/log"
 	"github.com/garden/observability-commons/metrics"
 	"github.com/garden/observability-commons/trace"
+	"go.opentelemetry.io/otel"
 )
 
 // Observability provides a unified interface for logging, metrics, and tracing
@@ -18,15 +21,39 @@ type Observability interface {
 	Error(component, operation, message string, err error, fields map[string]string)
 	Fatal(component, operation, message string, err error, fields map[string]string)
 
+	// Context-aware logging methods, which link the entry to the span active in ctx
+	DebugCtx(ctx context.Context, component, operation, message string, fields map[string]string)
+	InfoCtx(ctx context.Context, component, operation, message string, fields map[string]string)
+	WarnCtx(ctx context.Context, component, operation, message string, err error, fields map[string]string)
+	ErrorCtx(ctx context.Context, component, operation, message string, err error, fields map[string]string)
+	FatalCtx(ctx context.Context, component, operation, message string, err error, fields map[string]string)
+
 	// Tracing methods
 	StartSpan(ctx context.Context, name string, opts ...trace.SpanOption) (context.Context, trace.Span)
 	AddEvent(ctx context.Context, name string, attributes map[string]string)
 	SetAttributes(ctx context.Context, attributes map[string]string)
+	RecordError(ctx context.Context, err error)
 
 	// Metrics methods
 	SystemMetricHistogram(ctx context.Context, metricName string, value float64, fields map[string]string) error
+	SystemMetricInt64Histogram(ctx context.Context, metricName string, value int64, fields map[string]string) error
+	RegisterHistogram(metricName string, unit string, boundaries []float64) error
 	SystemMetricCounter(ctx context.Context, metricName string, value int64, fields map[string]string) error
+	SystemMetricFloat64Counter(ctx context.Context, metricName string, value float64, fields map[string]string) error
+	SystemMetricUpDownCounter(ctx context.Context, metricName string, value int64, fields map[string]string) error
+	SystemMetricFloat64UpDownCounter(ctx context.Context, metricName string, value float64, fields map[string]string) error
 	SystemMetricGauge(ctx context.Context, metricName string, value int64, fields map[string]string) error
+	SystemMetricFloat64Gauge(ctx context.Context, metricName string, value float64, fields map[string]string) error
+	RemoveSystemMetricGauge(metricName string, fields map[string]string)
+	RegisterGaugeFunc(metricName string, function func(ctx context.Context) int64, fields map[string]string) (metrics.Unregister, error)
+	RegisterCounterFunc(metricName string, function func(ctx context.Context) int64, fields map[string]string) (metrics.Unregister, error)
+	PrometheusHandler() http.Handler
+
+	// DroppedLogEntries returns how many log entries were discarded by the LogOverflowPolicy or logged after Close
+	DroppedLogEntries() uint64
+	// RejectedMetricAttributeSets returns how many distinct attribute sets were recorded into an overflow series
+	// because their metric had reached MetricCardinalityLimit
+	RejectedMetricAttributeSets() uint64
 
 	// Resource management
 	Close() error
@@ -37,6 +64,7 @@ type ObservabilityClient struct {
 	logger log.Logger
 	tracer trace.Tracer
 	meter  metrics.Meter
+	exit   func(code int)
 }
 
 // NewObservability creates a new observability client with OTLP-based logging and improved instrumentation
@@ -55,22 +83,47 @@ func NewObservability(cfg config.Config) (*ObservabilityClient, error) {
 	// Initialize tracer
 	tracer, err := trace.NewTracer(cfg)
 	if err != nil {
+		closeAll(logger)
 		return nil, err
 	}
 
 	// Initialize metrics
 	meter, err := metrics.NewOtelMeter(cfg)
 	if err != nil {
+		closeAll(logger, tracer)
 		return nil, err
 	}
+	if cfg.RuntimeMetrics {
+		if err = meter.StartRuntimeCollector(); err != nil {
+			closeAll(logger, tracer, meter)
+			return nil, err
+		}
+	}
+	if cfg.ProcessMetrics {
+		if err = meter.StartProcessCollector(); err != nil {
+			closeAll(logger, tracer, meter)
+			return nil, err
+		}
+	}
 
 	return &ObservabilityClient{
 		logger: logger,
 		tracer: tracer,
 		meter:  meter,
+		exit:   cfg.ExitHook,
 	}, nil
 }
 
+// closeAll shuts down the components created before one failed. Their errors go to the OpenTelemetry error handler,
+// since NewObservability returns the failure.
+func closeAll(components ...io.Closer) {
+	for _, component := range components {
+		if err := component.Close(); err != nil {
+			otel.Handle(err)
+		}
+	}
+}
+
 // Logging methods
 func (obs *ObservabilityClient) Debug(component, operation, message string, fields map[string]string) {
 	obs.logger.Debug(&log.Entry{
@@ -113,7 +166,62 @@ func (obs *ObservabilityClient) Error(component, operation, message string, err
 }
 
 func (obs *ObservabilityClient) Fatal(component, operation, message string, err error, fields map[string]string) {
-	obs.logger.Fatal(&log.Entry{
+	obs.fatal(&log.Entry{
+		Component: component,
+		Operation: operation,
+		Message:   message,
+		Err:       err,
+		Fields:    fields,
+	})
+}
+
+func (obs *ObservabilityClient) DebugCtx(ctx context.Context, component, operation, message string, fields map[string]string) {
+	obs.logger.Debug(&log.Entry{
+		Ctx:       ctx,
+		Component: component,
+		Operation: operation,
+		Message:   message,
+		Err:       nil,
+		Fields:    fields,
+	})
+}
+
+func (obs *ObservabilityClient) InfoCtx(ctx context.Context, component, operation, message string, fields map[string]string) {
+	obs.logger.Info(&log.Entry{
+		Ctx:       ctx,
+		Component: component,
+		Operation: operation,
+		Message:   message,
+		Err:       nil,
+		Fields:    fields,
+	})
+}
+
+func (obs *ObservabilityClient) WarnCtx(ctx context.Context, component, operation, message string, err error, fields map[string]string) {
+	obs.logger.Warn(&log.Entry{
+		Ctx:       ctx,
+		Component: component,
+		Operation: operation,
+		Message:   message,
+		Err:       err,
+		Fields:    fields,
+	})
+}
+
+func (obs *ObservabilityClient) ErrorCtx(ctx context.Context, component, operation, message string, err error, fields map[string]string) {
+	obs.logger.Error(&log.Entry{
+		Ctx:       ctx,
+		Component: component,
+		Operation: operation,
+		Message:   message,
+		Err:       err,
+		Fields:    fields,
+	})
+}
+
+func (obs *ObservabilityClient) FatalCtx(ctx context.Context, component, operation, message string, err error, fields map[string]string) {
+	obs.fatal(&log.Entry{
+		Ctx:       ctx,
 		Component: component,
 		Operation: operation,
 		Message:   message,
@@ -122,6 +230,22 @@ func (obs *ObservabilityClient) Fatal(component, operation, message string, err
 	})
 }
 
+// fatal writes the entry synchronously, flushes and shuts down the tracer and meter so nothing recorded so far is lost,
+// and only then calls the exit hook.
+func (obs *ObservabilityClient) fatal(logEntry *log.Entry) {
+	obs.logger.Fatal(logEntry)
+
+	if err := obs.tracer.Close(); err != nil {
+		otel.Handle(err)
+	}
+
+	if err := obs.meter.Close(); err != nil {
+		otel.Handle(err)
+	}
+
+	obs.exit(1)
+}
+
 // Tracing methods
 func (obs *ObservabilityClient) StartSpan(ctx context.Context, name string, opts ...trace.SpanOption) (context.Context, trace.Span) {
 	return obs.tracer.StartSpan(ctx, name, opts...)
@@ -135,19 +259,82 @@ func (obs *ObservabilityClient) SetAttributes(ctx context.Context, attributes ma
 	obs.tracer.SetAttributes(ctx, attributes)
 }
 
+func (obs *ObservabilityClient) RecordError(ctx context.Context, err error) {
+	obs.tracer.RecordError(ctx, err, nil)
+}
+
 // Metrics methods
 func (obs *ObservabilityClient) SystemMetricHistogram(ctx context.Context, metricName string, value float64, fields map[string]string) error {
 	return obs.meter.DefaultHistogram(ctx, metricName, value, fields)
 }
 
+// RegisterHistogram sets the unit and bucket boundaries of a histogram before its first value is recorded
+func (obs *ObservabilityClient) RegisterHistogram(metricName string, unit string, boundaries []float64) error {
+	return obs.meter.RegisterHistogram(metricName, unit, boundaries)
+}
+
+func (obs *ObservabilityClient) SystemMetricInt64Histogram(ctx context.Context, metricName string, value int64, fields map[string]string) error {
+	return obs.meter.DefaultInt64Histogram(ctx, metricName, value, fields)
+}
+
 func (obs *ObservabilityClient) SystemMetricCounter(ctx context.Context, metricName string, value int64, fields map[string]string) error {
 	return obs.meter.DefaultCounter(ctx, metricName, value, fields)
 }
 
+func (obs *ObservabilityClient) SystemMetricFloat64Counter(ctx context.Context, metricName string, value float64, fields map[string]string) error {
+	return obs.meter.DefaultFloat64Counter(ctx, metricName, value, fields)
+}
+
+// SystemMetricUpDownCounter adds value, which may be negative, to a sum that can go up and down
+func (obs *ObservabilityClient) SystemMetricUpDownCounter(ctx context.Context, metricName string, value int64, fields map[string]string) error {
+	return obs.meter.DefaultUpDownCounter(ctx, metricName, value, fields)
+}
+
+func (obs *ObservabilityClient) SystemMetricFloat64UpDownCounter(ctx context.Context, metricName string, value float64, fields map[string]string) error {
+	return obs.meter.DefaultFloat64UpDownCounter(ctx, metricName, value, fields)
+}
+
 func (obs *ObservabilityClient) SystemMetricGauge(ctx context.Context, metricName string, value int64, fields map[string]string) error {
 	return obs.meter.DefaultGauge(ctx, metricName, value, fields)
 }
 
+func (obs *ObservabilityClient) SystemMetricFloat64Gauge(ctx context.Context, metricName string, value float64, fields map[string]string) error {
+	return obs.meter.DefaultFloat64Gauge(ctx, metricName, value, fields)
+}
+
+// RemoveSystemMetricGauge stops reporting the gauge series identified by fields
+func (obs *ObservabilityClient) RemoveSystemMetricGauge(metricName string, fields map[string]string) {
+	obs.meter.RemoveGauge(metricName, fields)
+}
+
+// RegisterGaugeFunc samples function at each metrics collection until the returned Unregister is called
+func (obs *ObservabilityClient) RegisterGaugeFunc(metricName string, function func(ctx context.Context) int64, fields map[string]string) (metrics.Unregister, error) {
+	return obs.meter.RegisterGaugeFunc(metricName, function, fields)
+}
+
+// RegisterCounterFunc samples the running total returned by function at each metrics collection until the returned
+// Unregister is called
+func (obs *ObservabilityClient) RegisterCounterFunc(metricName string, function func(ctx context.Context) int64, fields map[string]string) (metrics.Unregister, error) {
+	return obs.meter.RegisterCounterFunc(metricName, function, fields)
+}
+
+// PrometheusHandler serves every SystemMetric* instrument in the Prometheus text format when config.MetricExporter
+// serves Prometheus, e.g. mounted on /metrics
+func (obs *ObservabilityClient) PrometheusHandler() http.Handler {
+	return obs.meter.PrometheusHandler()
+}
+
+// DroppedLogEntries returns how many log entries were discarded by the LogOverflowPolicy or logged after Close
+func (obs *ObservabilityClient) DroppedLogEntries() uint64 {
+	return obs.logger.DroppedEntries()
+}
+
+// RejectedMetricAttributeSets returns how many distinct attribute sets were recorded into an overflow series because
+// their metric had reached MetricCardinalityLimit
+func (obs *ObservabilityClient) RejectedMetricAttributeSets() uint64 {
+	return obs.meter.RejectedAttributeSets()
+}
+
 // Close gracefully shuts down all observability components
 func (obs *ObservabilityClient) Close() error {
 	// Close logger
@@ -160,5 +347,10 @@ func (obs *ObservabilityClient) Close() error {
 		return err
 	}
 
+	// Close meter
+	if err := obs.meter.Close(); err != nil {
+		return err
+	}
+
 	return nil
 }
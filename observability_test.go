@@ -0,0 +1,96 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/garden/observability-commons/log"
+	"github.com/garden/observability-commons/metrics"
+	"github.com/garden/observability-commons/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+// callRecorder keeps the order in which the fakes below are called
+type callRecorder struct {
+	calls []string
+}
+
+func (recorder *callRecorder) record(call string) {
+	recorder.calls = append(recorder.calls, call)
+}
+
+type fakeLogger struct {
+	log.Logger
+	recorder *callRecorder
+}
+
+func (logger fakeLogger) Fatal(logEntry *log.Entry) {
+	logger.recorder.record("logger.Fatal:" + logEntry.Message)
+}
+
+type fakeTracer struct {
+	trace.Tracer
+	recorder *callRecorder
+}
+
+func (tracer fakeTracer) Close() error {
+	tracer.recorder.record("tracer.Close")
+	return nil
+}
+
+type fakeMeter struct {
+	metrics.Meter
+	recorder *callRecorder
+}
+
+func (meter fakeMeter) Close() error {
+	meter.recorder.record("meter.Close")
+	return nil
+}
+
+func newFakeClient(recorder *callRecorder) *ObservabilityClient {
+	return &ObservabilityClient{
+		logger: fakeLogger{recorder: recorder},
+		tracer: fakeTracer{recorder: recorder},
+		meter:  fakeMeter{recorder: recorder},
+		exit: func(code int) {
+			recorder.record(fmt.Sprintf("exit:%d", code))
+		},
+	}
+}
+
+func TestObservabilityClient_Fatal(t *testing.T) {
+	tests := []struct {
+		name  string
+		fatal func(obs *ObservabilityClient)
+	}{
+		{
+			name: "Fatal",
+			fatal: func(obs *ObservabilityClient) {
+				obs.Fatal("order-service", "process-order", "database unreachable", errors.New("dial tcp: timeout"), nil)
+			},
+		},
+		{
+			name: "FatalCtx",
+			fatal: func(obs *ObservabilityClient) {
+				obs.FatalCtx(context.Background(), "order-service", "process-order", "database unreachable", errors.New("dial tcp: timeout"), nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &callRecorder{}
+
+			tt.fatal(newFakeClient(recorder))
+
+			assert.Equal(t, []string{
+				"logger.Fatal:database unreachable",
+				"tracer.Close",
+				"meter.Close",
+				"exit:1",
+			}, recorder.calls)
+		})
+	}
+}
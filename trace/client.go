@@ -0,0 +1,16 @@
+package trace
+
+import (
+	"github.com/garden/observability-commons/config"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+)
+
+func newClient(cfg config.Config) otlptrace.Client {
+	return otlptracegrpc.NewClient(
+		otlptracegrpc.WithInsecure(),
+		otlptracegrpc.WithEndpoint(cfg.GetEndpoint()),
+		otlptracegrpc.WithTimeout(cfg.Timeout),
+		otlptracegrpc.WithHeaders(cfg.OTLPHeaders),
+	)
+}
@@ -0,0 +1,64 @@
+package trace
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/exp/maps"
+)
+
+// SpanOption configures a span created by Tracer.StartSpan.
+type SpanOption func(*spanConfig)
+
+type spanConfig struct {
+	attributes map[string]string
+	startOpts  []trace.SpanStartOption
+}
+
+func newSpanConfig(opts ...SpanOption) spanConfig {
+	var cfg spanConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithSpanKind sets the role of the span, e.g. trace.SpanKindServer for an incoming request. Spans are
+// trace.SpanKindInternal by default.
+func WithSpanKind(kind trace.SpanKind) SpanOption {
+	return func(cfg *spanConfig) {
+		cfg.startOpts = append(cfg.startOpts, trace.WithSpanKind(kind))
+	}
+}
+
+// WithAttributes sets attributes on the span as it starts, merged with Config.DefaultFields the same way
+// Tracer.SetAttributes does. Several WithAttributes options are merged, the last one winning on conflicting keys.
+func WithAttributes(attributes map[string]string) SpanOption {
+	return func(cfg *spanConfig) {
+		if cfg.attributes == nil {
+			cfg.attributes = make(map[string]string, len(attributes))
+		}
+		maps.Copy(cfg.attributes, attributes)
+	}
+}
+
+// WithLinks links the span to other spans, e.g. the producers of the messages a batch consumer is handling.
+func WithLinks(links ...trace.Link) SpanOption {
+	return func(cfg *spanConfig) {
+		cfg.startOpts = append(cfg.startOpts, trace.WithLinks(links...))
+	}
+}
+
+// WithTimestamp sets the start time of the span instead of using the time StartSpan is called.
+func WithTimestamp(timestamp time.Time) SpanOption {
+	return func(cfg *spanConfig) {
+		cfg.startOpts = append(cfg.startOpts, trace.WithTimestamp(timestamp))
+	}
+}
+
+// AsNewRoot starts a new trace, ignoring any span already present in the context.
+func AsNewRoot() SpanOption {
+	return func(cfg *spanConfig) {
+		cfg.startOpts = append(cfg.startOpts, trace.WithNewRoot())
+	}
+}
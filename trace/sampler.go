@@ -0,0 +1,23 @@
+package trace
+
+import (
+	"github.com/garden/observability-commons/config"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func newSampler(cfg config.Config) sdktrace.Sampler {
+	switch cfg.TraceSampler {
+	case config.AlwaysOnSampler:
+		return sdktrace.AlwaysSample()
+	case config.AlwaysOffSampler:
+		return sdktrace.NeverSample()
+	case config.TraceIDRatioSampler:
+		return sdktrace.TraceIDRatioBased(cfg.GetTraceSamplerRatio())
+	case config.ParentBasedAlwaysOffSampler:
+		return sdktrace.ParentBased(sdktrace.NeverSample())
+	case config.ParentBasedTraceIDRatioSampler:
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.GetTraceSamplerRatio()))
+	default:
+		return sdktrace.ParentBased(sdktrace.AlwaysSample())
+	}
+}
@@ -0,0 +1,34 @@
+package trace
+
+import (
+	"testing"
+
+	"github.com/garden/observability-commons/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSampler(t *testing.T) {
+	tests := []struct {
+		name    string
+		sampler config.Sampler
+		ratio   *float64
+		want    string
+	}{
+		{name: "default", sampler: config.ParentBasedAlwaysOnSampler, want: "ParentBased{root:AlwaysOnSampler"},
+		{name: "always off", sampler: config.AlwaysOffSampler, want: "AlwaysOffSampler"},
+		{name: "ratio", sampler: config.TraceIDRatioSampler, ratio: floatPointer(0.25), want: "TraceIDRatioBased{0.25}"},
+		{name: "parent based ratio", sampler: config.ParentBasedTraceIDRatioSampler, ratio: floatPointer(0.5), want: "ParentBased{root:TraceIDRatioBased{0.5}"},
+		{name: "ratio defaults to every trace", sampler: config.TraceIDRatioSampler, want: "AlwaysOnSampler"},
+		{name: "zero ratio", sampler: config.TraceIDRatioSampler, ratio: floatPointer(0), want: "TraceIDRatioBased{0}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sampler := newSampler(config.Config{TraceSampler: tt.sampler, TraceSamplerRatio: tt.ratio})
+			assert.Contains(t, sampler.Description(), tt.want)
+		})
+	}
+}
+
+func floatPointer(value float64) *float64 {
+	return &value
+}
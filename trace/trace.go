@@ -3,12 +3,17 @@ package trace
 import (
 	"context"
 	"fmt"
+	"runtime/debug"
 
 	"github.com/garden/observability-commons/config"
+	"github.com/garden/observability-commons/util"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -20,15 +25,16 @@ type Span interface {
 	End()
 	AddEvent(name string, attributes map[string]string)
 	SetAttributes(attributes map[string]string)
+	RecordError(err error, fields map[string]string)
+	SetStatus(code codes.Code, description string)
 	SpanContext() trace.SpanContext
 }
 
-type SpanOption func()
-
 type Tracer interface {
 	StartSpan(ctx context.Context, name string, opts ...SpanOption) (context.Context, Span)
 	AddEvent(ctx context.Context, name string, attributes map[string]string)
 	SetAttributes(ctx context.Context, attributes map[string]string)
+	RecordError(ctx context.Context, err error, fields map[string]string)
 	Close() error
 }
 
@@ -41,23 +47,37 @@ type OtelTracer struct {
 func NewTracer(cfg config.Config) (*OtelTracer, error) {
 	ctx := context.Background()
 
-	res, err := resource.New(ctx,
-		resource.WithAttributes(
-			attribute.String("service.name", cfg.Service.Name),
-			attribute.String("service.version", cfg.Service.Version),
-			attribute.String("host.name", cfg.GetHostname()),
-		),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create resource: %w", err)
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Mode {
+	case config.Noop:
+	case config.Local:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("error creating otel exporter: %w", err)
+		}
+	case config.Debug, config.Development, config.Production:
+		exporter, err = otlptrace.New(ctx, newClient(cfg))
+		if err != nil {
+			return nil, fmt.Errorf("error creating otel exporter: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("error creating otel tracer: unknown mode %v", cfg.Mode)
 	}
 
-	exporter := &noopExporter{}
+	providerOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(cfg.GetResource()),
+		sdktrace.WithSampler(newSampler(cfg)),
+	}
+	// Noop keeps a provider without processors, so spans still carry valid contexts but are never exported
+	if exporter != nil {
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(exporter,
+			sdktrace.WithBatchTimeout(cfg.FlushInterval),
+			sdktrace.WithExportTimeout(cfg.Timeout),
+		))
+	}
 
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exporter),
-		sdktrace.WithResource(res),
-	)
+	tp := sdktrace.NewTracerProvider(providerOpts...)
 
 	otel.SetTracerProvider(tp)
 
@@ -69,31 +89,99 @@ func NewTracer(cfg config.Config) (*OtelTracer, error) {
 }
 
 func (t *OtelTracer) StartSpan(ctx context.Context, name string, opts ...SpanOption) (context.Context, Span) {
-	spanCtx, span := t.tracer.Start(ctx, name)
-	return spanCtx, &otelSpan{span: span}
+	spanCfg := newSpanConfig(opts...)
+
+	startOpts := spanCfg.startOpts
+	if spanCfg.attributes != nil {
+		startOpts = append(startOpts, trace.WithAttributes(t.attrs(spanCfg.attributes)...))
+	}
+
+	spanCtx, span := t.tracer.Start(ctx, name, startOpts...)
+	return spanCtx, &otelSpan{span: span, tracer: t}
 }
 
+// AddEvent records an event on the span active in ctx. It does nothing when ctx carries no recording span.
 func (t *OtelTracer) AddEvent(ctx context.Context, name string, attributes map[string]string) {
-	span := trace.SpanFromContext(ctx)
-	if span != nil {
-	}
+	t.addEvent(trace.SpanFromContext(ctx), name, attributes)
 }
 
+// SetAttributes sets attributes on the span active in ctx. It does nothing when ctx carries no recording span.
 func (t *OtelTracer) SetAttributes(ctx context.Context, attributes map[string]string) {
-	span := trace.SpanFromContext(ctx)
-	if span != nil {
-	}
+	t.setAttributes(trace.SpanFromContext(ctx), attributes)
+}
+
+// RecordError records err on the span active in ctx and marks it as failed. It does nothing when ctx carries no
+// recording span.
+func (t *OtelTracer) RecordError(ctx context.Context, err error, fields map[string]string) {
+	t.recordError(trace.SpanFromContext(ctx), err, fields)
 }
 
+// Close exports every span already ended and shuts the provider down, giving up after cfg.Timeout.
 func (t *OtelTracer) Close() error {
 	if t.tp != nil {
-		return t.tp.Shutdown(context.Background())
+		ctx, cancel := context.WithTimeout(context.Background(), t.cfg.Timeout)
+		defer cancel()
+
+		if err := t.tp.ForceFlush(ctx); err != nil {
+			return err
+		}
+		return t.tp.Shutdown(ctx)
 	}
 	return nil
 }
 
+func (t *OtelTracer) addEvent(span trace.Span, name string, attributes map[string]string) {
+	if !span.IsRecording() {
+		return
+	}
+	span.AddEvent(name, trace.WithAttributes(t.attrs(attributes)...))
+}
+
+func (t *OtelTracer) setAttributes(span trace.Span, attributes map[string]string) {
+	if !span.IsRecording() {
+		return
+	}
+	span.SetAttributes(t.attrs(attributes)...)
+}
+
+// recordError adds an exception event following the OpenTelemetry semantic conventions and sets the span status to
+// Error, so failed operations don't show up as successful spans.
+func (t *OtelTracer) recordError(span trace.Span, err error, fields map[string]string) {
+	if err == nil || !span.IsRecording() {
+		return
+	}
+
+	chain := util.GetErrorChain(err)
+	attrs := append(t.attrs(fields),
+		semconv.ExceptionTypeKey.String(util.GetErrorName(util.GetRootCause(err))),
+		semconv.ExceptionMessageKey.String(err.Error()),
+		attribute.Key("error.chain.type").StringSlice(chain.Types()),
+		attribute.Key("error.chain.message").StringSlice(chain.Messages()),
+	)
+	if t.cfg.RecordErrorStacktrace {
+		stacktrace := debug.Stack()
+		attrs = append(attrs,
+			semconv.ExceptionStacktraceKey.String(string(stacktrace)),
+			attribute.Key("stacktrace.hash").String(util.ParseStacktrace(stacktrace).Fingerprint(false)),
+		)
+	}
+
+	span.AddEvent(semconv.ExceptionEventName, trace.WithAttributes(attrs...))
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// attrs merges cfg.DefaultFields with the given attributes, the latter taking precedence on conflicting keys.
+func (t *OtelTracer) attrs(attributes map[string]string) []attribute.KeyValue {
+	fields := util.ExtraFields{}
+	if t.cfg.DefaultFields != nil {
+		fields = util.MergeExtraFields(fields, *t.cfg.DefaultFields)
+	}
+	return util.MergeExtraFields(fields, attributes).ToAttrs()
+}
+
 type otelSpan struct {
-	span trace.Span
+	span   trace.Span
+	tracer *OtelTracer
 }
 
 func (s *otelSpan) End() {
@@ -101,21 +189,21 @@ func (s *otelSpan) End() {
 }
 
 func (s *otelSpan) AddEvent(name string, attributes map[string]string) {
+	s.tracer.addEvent(s.span, name, attributes)
 }
 
 func (s *otelSpan) SetAttributes(attributes map[string]string) {
+	s.tracer.setAttributes(s.span, attributes)
 }
 
-func (s *otelSpan) SpanContext() trace.SpanContext {
-	return s.span.SpanContext()
+func (s *otelSpan) RecordError(err error, fields map[string]string) {
+	s.tracer.recordError(s.span, err, fields)
 }
 
-type noopExporter struct{}
-
-func (e *noopExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
-	return nil
+func (s *otelSpan) SetStatus(code codes.Code, description string) {
+	s.span.SetStatus(code, description)
 }
 
-func (e *noopExporter) Shutdown(ctx context.Context) error {
-	return nil
+func (s *otelSpan) SpanContext() trace.SpanContext {
+	return s.span.SpanContext()
 }
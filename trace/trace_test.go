@@ -0,0 +1,291 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/garden/observability-commons/config"
+	"github.com/garden/observability-commons/util"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestTracer(cfg config.Config) (*OtelTracer, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	return &OtelTracer{
+		tracer: tp.Tracer(instrumentationName),
+		tp:     tp,
+		cfg:    cfg,
+	}, recorder
+}
+
+func attrMap(attrs []attribute.KeyValue) map[string]string {
+	m := make(map[string]string, len(attrs))
+	for _, attr := range attrs {
+		m[string(attr.Key)] = attr.Value.Emit()
+	}
+	return m
+}
+
+func TestOtelTracer_AddEvent(t *testing.T) {
+	tests := []struct {
+		name          string
+		defaultFields *map[string]string
+		attributes    map[string]string
+		want          map[string]string
+	}{
+		{
+			name:       "no default fields",
+			attributes: map[string]string{"count": "5"},
+			want:       map[string]string{"count": "5"},
+		},
+		{
+			name:          "merges default fields",
+			defaultFields: &map[string]string{"team": "payments"},
+			attributes:    map[string]string{"count": "5"},
+			want:          map[string]string{"count": "5", "team": "payments"},
+		},
+		{
+			name:          "attributes override default fields",
+			defaultFields: &map[string]string{"team": "payments"},
+			attributes:    map[string]string{"team": "orders"},
+			want:          map[string]string{"team": "orders"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer, recorder := newTestTracer(config.Config{DefaultFields: tt.defaultFields})
+
+			ctx, span := tracer.StartSpan(context.Background(), "process-orders")
+			tracer.AddEvent(ctx, "orders-processed", tt.attributes)
+			span.End()
+
+			ended := recorder.Ended()
+			if assert.Len(t, ended, 1) && assert.Len(t, ended[0].Events(), 1) {
+				event := ended[0].Events()[0]
+				assert.Equal(t, "orders-processed", event.Name)
+				assert.Equal(t, tt.want, attrMap(event.Attributes))
+			}
+		})
+	}
+}
+
+func TestOtelTracer_SetAttributes(t *testing.T) {
+	tracer, recorder := newTestTracer(config.Config{DefaultFields: &map[string]string{"team": "payments"}})
+
+	ctx, span := tracer.StartSpan(context.Background(), "process-orders")
+	tracer.SetAttributes(ctx, map[string]string{"order_id": "order-1"})
+	span.End()
+
+	ended := recorder.Ended()
+	if assert.Len(t, ended, 1) {
+		assert.Equal(t, map[string]string{"order_id": "order-1", "team": "payments"}, attrMap(ended[0].Attributes()))
+	}
+}
+
+func TestOtelSpan_AddEventAndSetAttributes(t *testing.T) {
+	tracer, recorder := newTestTracer(config.Config{})
+
+	_, span := tracer.StartSpan(context.Background(), "process-orders")
+	span.AddEvent("payment-processed", map[string]string{"amount": "99.99"})
+	span.SetAttributes(map[string]string{"currency": "USD"})
+	span.End()
+
+	ended := recorder.Ended()
+	if assert.Len(t, ended, 1) {
+		assert.Equal(t, map[string]string{"currency": "USD"}, attrMap(ended[0].Attributes()))
+		if assert.Len(t, ended[0].Events(), 1) {
+			assert.Equal(t, "payment-processed", ended[0].Events()[0].Name)
+			assert.Equal(t, map[string]string{"amount": "99.99"}, attrMap(ended[0].Events()[0].Attributes))
+		}
+	}
+}
+
+func TestOtelTracer_NoActiveSpan(t *testing.T) {
+	tracer, recorder := newTestTracer(config.Config{})
+
+	assert.NotPanics(t, func() {
+		tracer.AddEvent(context.Background(), "orphan-event", map[string]string{"count": "1"})
+		tracer.SetAttributes(context.Background(), map[string]string{"count": "1"})
+	})
+	assert.Empty(t, recorder.Ended())
+}
+
+func TestOtelTracer_StartSpanOptions(t *testing.T) {
+	tracer, recorder := newTestTracer(config.Config{DefaultFields: &map[string]string{"team": "payments"}})
+	start := time.Date(2022, time.September, 1, 12, 0, 0, 0, time.UTC)
+
+	parentCtx, parent := tracer.StartSpan(context.Background(), "parent")
+	_, linked := tracer.StartSpan(context.Background(), "linked")
+
+	_, span := tracer.StartSpan(parentCtx, "process-orders",
+		WithSpanKind(trace.SpanKindServer),
+		WithAttributes(map[string]string{"order_id": "order-1"}),
+		WithAttributes(map[string]string{"currency": "USD"}),
+		WithLinks(trace.Link{SpanContext: linked.SpanContext()}),
+		WithTimestamp(start),
+		AsNewRoot(),
+	)
+	span.End()
+	linked.End()
+	parent.End()
+
+	ended := recorder.Ended()
+	if assert.Len(t, ended, 3) {
+		got := ended[0]
+		assert.Equal(t, "process-orders", got.Name())
+		assert.Equal(t, trace.SpanKindServer, got.SpanKind())
+		assert.Equal(t, map[string]string{"order_id": "order-1", "currency": "USD", "team": "payments"}, attrMap(got.Attributes()))
+		assert.Equal(t, start, got.StartTime())
+		assert.False(t, got.Parent().IsValid(), "a new root span has no parent")
+		assert.NotEqual(t, parent.SpanContext().TraceID(), got.SpanContext().TraceID())
+		if assert.Len(t, got.Links(), 1) {
+			assert.Equal(t, linked.SpanContext().SpanID(), got.Links()[0].SpanContext.SpanID())
+		}
+	}
+}
+
+func TestOtelTracer_StartSpanDefaults(t *testing.T) {
+	tracer, recorder := newTestTracer(config.Config{DefaultFields: &map[string]string{"team": "payments"}})
+
+	parentCtx, parent := tracer.StartSpan(context.Background(), "parent")
+	_, span := tracer.StartSpan(parentCtx, "child")
+	span.End()
+	parent.End()
+
+	ended := recorder.Ended()
+	if assert.Len(t, ended, 2) {
+		child := ended[0]
+		assert.Equal(t, trace.SpanKindInternal, child.SpanKind())
+		assert.Empty(t, child.Attributes())
+		assert.Equal(t, parent.SpanContext().SpanID(), child.Parent().SpanID())
+	}
+}
+
+type paymentError struct {
+	msg string
+}
+
+func (err paymentError) Error() string {
+	return err.msg
+}
+
+func TestOtelSpan_RecordError(t *testing.T) {
+	tests := []struct {
+		name           string
+		stacktrace     bool
+		err            error
+		fields         map[string]string
+		wantType       string
+		wantStacktrace bool
+	}{
+		{
+			name:     "std error",
+			err:      errors.New("payment failed"),
+			wantType: "errors.errorString",
+		},
+		{
+			name:     "custom error with fields",
+			err:      paymentError{msg: "payment failed"},
+			fields:   map[string]string{"order_id": "order-1"},
+			wantType: "github.com/garden/observability-commons/trace.paymentError",
+		},
+		{
+			name:           "with stacktrace",
+			stacktrace:     true,
+			err:            paymentError{msg: "payment failed"},
+			wantType:       "github.com/garden/observability-commons/trace.paymentError",
+			wantStacktrace: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer, recorder := newTestTracer(config.Config{RecordErrorStacktrace: tt.stacktrace})
+
+			_, span := tracer.StartSpan(context.Background(), "process-payment")
+			span.RecordError(tt.err, tt.fields)
+			span.End()
+
+			ended := recorder.Ended()
+			if !assert.Len(t, ended, 1) || !assert.Len(t, ended[0].Events(), 1) {
+				return
+			}
+			assert.Equal(t, codes.Error, ended[0].Status().Code)
+			assert.Equal(t, "payment failed", ended[0].Status().Description)
+
+			event := ended[0].Events()[0]
+			attrs := attrMap(event.Attributes)
+			assert.Equal(t, "exception", event.Name)
+			assert.Equal(t, tt.wantType, attrs["exception.type"])
+			assert.Equal(t, "payment failed", attrs["exception.message"])
+			for key, value := range tt.fields {
+				assert.Equal(t, value, attrs[key])
+			}
+			if tt.wantStacktrace {
+				assert.NotEmpty(t, attrs["exception.stacktrace"])
+				assert.Equal(t, util.ParseStacktrace([]byte(attrs["exception.stacktrace"])).Fingerprint(false), attrs["stacktrace.hash"])
+			} else {
+				assert.NotContains(t, attrs, "exception.stacktrace")
+				assert.NotContains(t, attrs, "stacktrace.hash")
+			}
+		})
+	}
+}
+
+func TestOtelSpan_RecordError_Chain(t *testing.T) {
+	tracer, recorder := newTestTracer(config.Config{})
+
+	_, span := tracer.StartSpan(context.Background(), "process-payment")
+	span.RecordError(fmt.Errorf("error processing order: %w", paymentError{msg: "payment failed"}), nil)
+	span.End()
+
+	ended := recorder.Ended()
+	if !assert.Len(t, ended, 1) || !assert.Len(t, ended[0].Events(), 1) {
+		return
+	}
+
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, attr := range ended[0].Events()[0].Attributes {
+		attrs[attr.Key] = attr.Value
+	}
+	assert.Equal(t, "github.com/garden/observability-commons/trace.paymentError", attrs["exception.type"].AsString())
+	assert.Equal(t, "error processing order: payment failed", attrs["exception.message"].AsString())
+	assert.Equal(t, []string{"fmt.wrapError", "github.com/garden/observability-commons/trace.paymentError"}, attrs["error.chain.type"].AsStringSlice())
+	assert.Equal(t, []string{"error processing order: payment failed", "payment failed"}, attrs["error.chain.message"].AsStringSlice())
+}
+
+func TestOtelTracer_RecordError(t *testing.T) {
+	tracer, recorder := newTestTracer(config.Config{})
+
+	ctx, span := tracer.StartSpan(context.Background(), "process-payment")
+	tracer.RecordError(ctx, nil, nil)
+	tracer.RecordError(ctx, errors.New("payment failed"), nil)
+	span.End()
+
+	ended := recorder.Ended()
+	if assert.Len(t, ended, 1) {
+		assert.Len(t, ended[0].Events(), 1, "a nil error must not be recorded")
+		assert.Equal(t, codes.Error, ended[0].Status().Code)
+	}
+}
+
+func TestOtelSpan_SetStatus(t *testing.T) {
+	tracer, recorder := newTestTracer(config.Config{})
+
+	_, span := tracer.StartSpan(context.Background(), "process-payment")
+	span.SetStatus(codes.Ok, "")
+	span.End()
+
+	ended := recorder.Ended()
+	if assert.Len(t, ended, 1) {
+		assert.Equal(t, codes.Ok, ended[0].Status().Code)
+	}
+}
@@ -0,0 +1,73 @@
+package util
+
+import (
+	"runtime/debug"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// BuildInfo describes the binary, as embedded by the Go toolchain. Fields are empty when the binary was built without
+// module or VCS information, e.g. with -buildvcs=false.
+type BuildInfo struct {
+	GoVersion string
+	// ModuleVersion is the version of the main module, "(devel)" when built from a checkout
+	ModuleVersion string
+	// Revision is the VCS revision the binary was built from
+	Revision string
+	// Time is the time of the revision, Go doesn't embed the time of the build itself
+	Time string
+	// Modified reports uncommitted changes in the checkout at build time
+	Modified bool
+}
+
+var (
+	buildInfoOnce sync.Once
+	buildInfo     BuildInfo
+)
+
+// ReadBuildInfo returns the build information of the running binary, read once.
+func ReadBuildInfo() BuildInfo {
+	buildInfoOnce.Do(func() {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			buildInfo = newBuildInfo(info)
+		}
+	})
+	return buildInfo
+}
+
+func newBuildInfo(info *debug.BuildInfo) BuildInfo {
+	build := BuildInfo{GoVersion: info.GoVersion, ModuleVersion: info.Main.Version}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Revision = setting.Value
+		case "vcs.time":
+			build.Time = setting.Value
+		case "vcs.modified":
+			build.Modified = setting.Value == "true"
+		}
+	}
+	return build
+}
+
+// Attributes returns the build information as resource attributes, without the empty fields.
+func (build BuildInfo) Attributes() []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, 5)
+	if build.GoVersion != "" {
+		attrs = append(attrs, attribute.String("process.runtime.version", build.GoVersion))
+	}
+	if build.ModuleVersion != "" {
+		attrs = append(attrs, attribute.String("garden.build.version", build.ModuleVersion))
+	}
+	if build.Revision != "" {
+		attrs = append(attrs,
+			attribute.String("garden.build.revision", build.Revision),
+			attribute.Bool("garden.build.modified", build.Modified),
+		)
+	}
+	if build.Time != "" {
+		attrs = append(attrs, attribute.String("garden.build.time", build.Time))
+	}
+	return attrs
+}
@@ -0,0 +1,55 @@
+package util
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestBuildInfo_Attributes(t *testing.T) {
+	tests := []struct {
+		name string
+		info *debug.BuildInfo
+		want []attribute.KeyValue
+	}{
+		{
+			name: "built from a modified checkout",
+			info: &debug.BuildInfo{
+				GoVersion: "go1.18.3",
+				Main:      debug.Module{Path: "github.com/garden/checkout", Version: "(devel)"},
+				Settings: []debug.BuildSetting{
+					{Key: "-compiler", Value: "gc"},
+					{Key: "vcs", Value: "git"},
+					{Key: "vcs.revision", Value: "4ca4ac6e1f"},
+					{Key: "vcs.time", Value: "2022-07-01T10:00:00Z"},
+					{Key: "vcs.modified", Value: "true"},
+				},
+			},
+			want: []attribute.KeyValue{
+				attribute.String("process.runtime.version", "go1.18.3"),
+				attribute.String("garden.build.version", "(devel)"),
+				attribute.String("garden.build.revision", "4ca4ac6e1f"),
+				attribute.Bool("garden.build.modified", true),
+				attribute.String("garden.build.time", "2022-07-01T10:00:00Z"),
+			},
+		},
+		{
+			name: "installed module without VCS information",
+			info: &debug.BuildInfo{
+				GoVersion: "go1.18.3",
+				Main:      debug.Module{Path: "github.com/garden/checkout", Version: "v1.4.0"},
+			},
+			want: []attribute.KeyValue{
+				attribute.String("process.runtime.version", "go1.18.3"),
+				attribute.String("garden.build.version", "v1.4.0"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, newBuildInfo(tt.info).Attributes())
+		})
+	}
+}
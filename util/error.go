@@ -1,17 +1,124 @@
 package util
 
 import (
+	"errors"
 	"reflect"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// maxErrorChainDepth bounds how many links ErrorChain walks, in case a broken Unwrap method returns its receiver
+	maxErrorChainDepth = 32
 )
 
+// GetErrorName returns the package-qualified name of the error's type, dereferencing pointers, so *os.PathError is
+// reported as "io/fs.PathError" instead of an anonymous pointer type.
 func GetErrorName(err error) string {
 	if err == nil {
 		return ""
 	}
 
-	if errorType := reflect.TypeOf(err); errorType != nil && errorType.Name() != "" {
+	errorType := reflect.TypeOf(err)
+	for errorType.Kind() == reflect.Pointer {
+		errorType = errorType.Elem()
+	}
+
+	if errorType.Name() == "" {
+		return "error"
+	}
+	if errorType.PkgPath() == "" {
 		return errorType.Name()
 	}
+	return errorType.PkgPath() + "." + errorType.Name()
+}
+
+type ErrorLink struct {
+	Type    string
+	Message string
+}
+
+type ErrorChain []ErrorLink
+
+// GetErrorChain walks err depth-first through errors.Unwrap and the Unwrap() []error method of joined errors,
+// returning the type and message of every link, outermost first.
+func GetErrorChain(err error) ErrorChain {
+	chain := ErrorChain{}
+	walkErrorChain(err, 0, func(err error) {
+		chain = append(chain, ErrorLink{Type: GetErrorName(err), Message: err.Error()})
+	})
+	return chain
+}
+
+func walkErrorChain(err error, depth int, visit func(error)) {
+	if err == nil || depth >= maxErrorChainDepth {
+		return
+	}
+
+	visit(err)
+	switch wrapper := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, wrapped := range wrapper.Unwrap() {
+			walkErrorChain(wrapped, depth+1, visit)
+		}
+	default:
+		walkErrorChain(errors.Unwrap(err), depth+1, visit)
+	}
+}
+
+// GetRootCause returns the innermost error of the chain. For joined errors it follows the first one.
+func GetRootCause(err error) error {
+	for depth := 0; err != nil && depth < maxErrorChainDepth; depth++ {
+		var wrapped error
+		switch wrapper := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, joined := range wrapper.Unwrap() {
+				if joined != nil {
+					wrapped = joined
+					break
+				}
+			}
+		default:
+			wrapped = errors.Unwrap(err)
+		}
+
+		if wrapped == nil {
+			return err
+		}
+		err = wrapped
+	}
+	return err
+}
 
-	return "error"
+// Types returns the type of every link, in chain order.
+func (chain ErrorChain) Types() []string {
+	types := make([]string, 0, len(chain))
+	for _, link := range chain {
+		types = append(types, link.Type)
+	}
+	return types
+}
+
+// Messages returns the message of every link, in chain order.
+func (chain ErrorChain) Messages() []string {
+	messages := make([]string, 0, len(chain))
+	for _, link := range chain {
+		messages = append(messages, link.Message)
+	}
+	return messages
+}
+
+func (link ErrorLink) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddString("type", link.Type)
+	encoder.AddString("message", link.Message)
+	return nil
+}
+
+func (chain ErrorChain) MarshalLogArray(encoder zapcore.ArrayEncoder) error {
+	for _, link := range chain {
+		if err := encoder.AppendObject(link); err != nil {
+			return err
+		}
+	}
+	return nil
 }
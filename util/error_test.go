@@ -2,7 +2,10 @@ package util
 
 import (
 	"errors"
+	"fmt"
 	"github.com/stretchr/testify/assert"
+	"io/fs"
+	"os"
 	"testing"
 )
 
@@ -33,14 +36,21 @@ func TestGetErrorName(t *testing.T) {
 			args: args{
 				err: errors.New("an error occurred in the test"),
 			},
-			want: "error",
+			want: "errors.errorString",
 		},
 		{
 			name: "custom error",
 			args: args{
 				err: customError{msg: "a custom error occurred in the test"},
 			},
-			want: "customError",
+			want: "github.com/garden/observability-commons/util.customError",
+		},
+		{
+			name: "pointer error",
+			args: args{
+				err: &fs.PathError{Op: "open", Path: "/missing", Err: fs.ErrNotExist},
+			},
+			want: "io/fs.PathError",
 		},
 	}
 	for _, tt := range tests {
@@ -50,3 +60,64 @@ func TestGetErrorName(t *testing.T) {
 		})
 	}
 }
+
+// joinedError mirrors errors.Join, which is not available on the Go version this module targets
+type joinedError []error
+
+func (errs joinedError) Error() string {
+	return fmt.Sprintf("%v", []error(errs))
+}
+
+func (errs joinedError) Unwrap() []error {
+	return errs
+}
+
+func TestGetErrorChain(t *testing.T) {
+	pathError := &fs.PathError{Op: "open", Path: "/missing", Err: fs.ErrNotExist}
+	tests := []struct {
+		name      string
+		err       error
+		want      ErrorChain
+		wantCause error
+	}{
+		{
+			name:      "no error",
+			err:       nil,
+			want:      ErrorChain{},
+			wantCause: nil,
+		},
+		{
+			name:      "single error",
+			err:       os.ErrClosed,
+			want:      ErrorChain{{Type: "errors.errorString", Message: "file already closed"}},
+			wantCause: os.ErrClosed,
+		},
+		{
+			name: "wrapped error",
+			err:  fmt.Errorf("error loading config: %w", pathError),
+			want: ErrorChain{
+				{Type: "fmt.wrapError", Message: "error loading config: open /missing: file does not exist"},
+				{Type: "io/fs.PathError", Message: "open /missing: file does not exist"},
+				{Type: "errors.errorString", Message: "file does not exist"},
+			},
+			wantCause: fs.ErrNotExist,
+		},
+		{
+			name: "joined errors",
+			err:  joinedError{customError{msg: "first"}, fmt.Errorf("second: %w", os.ErrClosed)},
+			want: ErrorChain{
+				{Type: "github.com/garden/observability-commons/util.joinedError", Message: "[first second: file already closed]"},
+				{Type: "github.com/garden/observability-commons/util.customError", Message: "first"},
+				{Type: "fmt.wrapError", Message: "second: file already closed"},
+				{Type: "errors.errorString", Message: "file already closed"},
+			},
+			wantCause: customError{msg: "first"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, GetErrorChain(tt.err))
+			assert.Equal(t, tt.wantCause, GetRootCause(tt.err))
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package util
+
+import (
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	modulePath = "github.com/garden/observability-commons"
+)
+
+type StackFrame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+type StackFrames []StackFrame
+
+// ParseStacktrace turns the output of runtime/debug.Stack into frames, innermost first. Goroutine headers, argument
+// values and program counter offsets are dropped, as are the frames of this module and runtime/debug.Stack itself, so
+// only the caller's code remains.
+func ParseStacktrace(stack []byte) StackFrames {
+	lines := strings.Split(string(stack), "\n")
+
+	frames := make(StackFrames, 0, len(lines)/2)
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if line == "" || strings.HasPrefix(line, "goroutine ") || strings.HasPrefix(line, "...") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+
+		frame := StackFrame{Function: parseFunction(line)}
+		if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\t") {
+			frame.File, frame.Line = parseLocation(lines[i+1])
+			i++
+		}
+
+		if isOwnFrame(frame.Function) {
+			continue
+		}
+		frames = append(frames, frame)
+	}
+
+	return frames
+}
+
+// parseFunction strips the argument values from lines like "main.(*server).handle(0xc000010000, {0x1, 0x2})" and the
+// goroutine ID from lines like "created by main.main in goroutine 1".
+func parseFunction(line string) string {
+	if strings.HasPrefix(line, "created by ") {
+		line = strings.TrimPrefix(line, "created by ")
+		if idx := strings.Index(line, " in goroutine "); idx >= 0 {
+			line = line[:idx]
+		}
+		return line
+	}
+
+	if strings.HasSuffix(line, ")") {
+		if idx := strings.LastIndex(line, "("); idx > 0 {
+			line = line[:idx]
+		}
+	}
+	return line
+}
+
+// parseLocation parses lines like "\t/app/main.go:42 +0x1d", dropping the program counter offset.
+func parseLocation(line string) (string, int) {
+	location := strings.TrimPrefix(line, "\t")
+	if idx := strings.LastIndex(location, " +0x"); idx >= 0 {
+		location = location[:idx]
+	}
+
+	idx := strings.LastIndex(location, ":")
+	if idx < 0 {
+		return location, 0
+	}
+
+	lineNumber, err := strconv.Atoi(location[idx+1:])
+	if err != nil {
+		return location, 0
+	}
+	return location[:idx], lineNumber
+}
+
+func isOwnFrame(function string) bool {
+	return function == "runtime/debug.Stack" ||
+		strings.HasPrefix(function, modulePath+".") ||
+		strings.HasPrefix(function, modulePath+"/")
+}
+
+// Fingerprint hashes the function and file of every frame, plus the line when withLines is set, so the same bug
+// produces the same hash no matter which goroutine hit it. Leaving lines out keeps the hash stable across releases
+// that only move code around.
+func (frames StackFrames) Fingerprint(withLines bool) string {
+	var builder strings.Builder
+	for _, frame := range frames {
+		builder.WriteString(frame.Function)
+		builder.WriteByte('\n')
+		builder.WriteString(frame.File)
+		if withLines {
+			builder.WriteByte(':')
+			builder.WriteString(strconv.Itoa(frame.Line))
+		}
+		builder.WriteByte('\n')
+	}
+	return MD5Hash([]byte(builder.String()))
+}
+
+func (frame StackFrame) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddString("function", frame.Function)
+	encoder.AddString("file", frame.File)
+	encoder.AddInt("line", frame.Line)
+	return nil
+}
+
+func (frames StackFrames) MarshalLogArray(encoder zapcore.ArrayEncoder) error {
+	for _, frame := range frames {
+		if err := encoder.AppendObject(frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const stackFixture = `goroutine 1 [running]:
+runtime/debug.Stack()
+	/usr/local/go/src/runtime/debug/stack.go:24 +0x65
+github.com/garden/observability-commons/log.(*OTLPLogger).Error(0xc0001a2000, 0xc0001b4000)
+	/go/pkg/mod/github.com/garden/observability-commons/log/otlp.go:106 +0x45
+github.com/garden/observability-commons.(*ObservabilityClient).Error(0xc0001a2000, {0x6f1a2b, 0xd}, {0x6f1b3c, 0xd})
+	/go/pkg/mod/github.com/garden/observability-commons/observability.go:120 +0x9a
+main.(*orderService).process(0xc000012345, {0x7f0000, 0xc000010000})
+	/app/orders.go:42 +0x1d
+main.main()
+	/app/main.go:17 +0x2c
+`
+
+const otherGoroutineStackFixture = `goroutine 73 [running]:
+runtime/debug.Stack()
+	/usr/local/go/src/runtime/debug/stack.go:24 +0x65
+github.com/garden/observability-commons/log.(*OTLPLogger).Error(0xc0009f0000, 0xc0009f8000)
+	/go/pkg/mod/github.com/garden/observability-commons/log/otlp.go:106 +0x45
+github.com/garden/observability-commons.(*ObservabilityClient).Error(0xc0009f0000, {0x6f1a2b, 0xd}, {0x6f1b3c, 0xd})
+	/go/pkg/mod/github.com/garden/observability-commons/observability.go:120 +0x9a
+main.(*orderService).process(0xc000abcdef, {0x7f0000, 0xc000fedcba})
+	/app/orders.go:42 +0x1d
+main.main()
+	/app/main.go:17 +0x2c
+`
+
+const createdByStackFixture = `goroutine 18 [running]:
+main.worker(0xc000020000)
+	/app/worker.go:12 +0x3b
+created by main.main in goroutine 1
+	/app/main.go:9 +0x25
+`
+
+func TestParseStacktrace(t *testing.T) {
+	tests := []struct {
+		name  string
+		stack string
+		want  StackFrames
+	}{
+		{
+			name:  "empty stack",
+			stack: "",
+			want:  StackFrames{},
+		},
+		{
+			name:  "drops own frames and volatile values",
+			stack: stackFixture,
+			want: StackFrames{
+				{Function: "main.(*orderService).process", File: "/app/orders.go", Line: 42},
+				{Function: "main.main", File: "/app/main.go", Line: 17},
+			},
+		},
+		{
+			name:  "created by",
+			stack: createdByStackFixture,
+			want: StackFrames{
+				{Function: "main.worker", File: "/app/worker.go", Line: 12},
+				{Function: "main.main", File: "/app/main.go", Line: 9},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseStacktrace([]byte(tt.stack))
+			assert.Equalf(t, tt.want, got, "ParseStacktrace() = %v, want %v", got, tt.want)
+		})
+	}
+}
+
+func TestStackFrames_Fingerprint(t *testing.T) {
+	frames := ParseStacktrace([]byte(stackFixture))
+	otherGoroutineFrames := ParseStacktrace([]byte(otherGoroutineStackFixture))
+
+	assert.Equal(t, frames.Fingerprint(false), otherGoroutineFrames.Fingerprint(false),
+		"goroutine IDs and argument values must not change the fingerprint")
+	assert.Equal(t, frames.Fingerprint(true), otherGoroutineFrames.Fingerprint(true))
+
+	moved := append(StackFrames(nil), frames...)
+	moved[0].Line = 50
+	assert.Equal(t, frames.Fingerprint(false), moved.Fingerprint(false), "lines are ignored unless requested")
+	assert.NotEqual(t, frames.Fingerprint(true), moved.Fingerprint(true))
+
+	renamed := append(StackFrames(nil), frames...)
+	renamed[0].Function = "main.(*orderService).cancel"
+	assert.NotEqual(t, frames.Fingerprint(false), renamed.Fingerprint(false))
+}